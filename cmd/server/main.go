@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/NarthurN/GoXML_JSON/internal/client"
 	"github.com/NarthurN/GoXML_JSON/internal/converter"
@@ -28,10 +30,93 @@ func main() {
 
 	logg.Log("✅ логгер инциализирован")
 
-	converter := converter.NewConverter()
+	ageSchemes, err := converter.LoadAgeGroupSchemes(settings.AgeGroupsFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		ageSchemes = converter.DefaultAgeGroupSchemes()
+		logg.Logf("⚠️ файл %s не найден, используются возрастные группы по умолчанию", settings.AgeGroupsFile)
+	case err != nil:
+		log.Fatalf("❌ не удалось загрузить возрастные группы: %v", err)
+	default:
+		logg.Logf("✅ схемы возрастных групп загружены из %s: %d", settings.AgeGroupsFile, len(ageSchemes.Schemes))
+	}
+
+	mappings, err := converter.LoadMappings(settings.MappingsDir)
+	if err != nil {
+		log.Fatalf("❌ не удалось загрузить маппинги полей: %v", err)
+	}
+	logg.Logf("✅ маппинги полей загружены из %s: %d", settings.MappingsDir, len(mappings))
+
+	if err := converter.ValidatePassthrough(settings.PassthroughMode); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := converter.ValidateDuplicatePolicy(settings.DuplicatePolicy); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	blockedDomains, err := converter.LoadEmailDomains(settings.BlockedEmailDomainsFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		logg.Logf("⚠️ файл %s не найден, домены email не проверяются", settings.BlockedEmailDomainsFile)
+	case err != nil:
+		log.Fatalf("❌ не удалось загрузить запрещенные домены email: %v", err)
+	default:
+		logg.Logf("✅ запрещенные домены email загружены из %s: %d", settings.BlockedEmailDomainsFile, len(blockedDomains))
+	}
+
+	converterOptions := []converter.Option{
+		converter.WithAgeGroupSchemes(ageSchemes),
+		converter.WithLanguage(settings.LabelLanguage),
+		converter.WithMappings(mappings),
+		converter.WithPassthrough(settings.PassthroughMode),
+		converter.WithNamespaces(converter.ParseNamespaces(settings.AcceptedNamespaces)...),
+		converter.WithBlockedEmailDomains(blockedDomains...),
+		converter.WithDuplicatePolicy(settings.DuplicatePolicy),
+		converter.WithNameTitleCase(settings.NameTitleCase),
+		converter.WithHomoglyphWarnings(settings.HomoglyphWarnings),
+		converter.WithLimits(converter.Limits{
+			MaxDepth:       settings.MaxXMLDepth,
+			MaxUsers:       settings.MaxUsers,
+			MaxStreamUsers: settings.StreamMaxUsers,
+			MaxTextLength:  settings.MaxTextLength,
+			MaxAttrLength:  settings.MaxAttrLength,
+		}),
+	}
+	if settings.AsOfDate != "" {
+		asOf, err := time.Parse(time.DateOnly, settings.AsOfDate)
+		if err != nil {
+			log.Fatalf("❌ некорректная дата расчета возраста: %v", err)
+		}
+		converterOptions = append(converterOptions, converter.WithAsOfDate(asOf))
+	}
+	if settings.SchemaFile != "" {
+		schema, err := converter.LoadSchema(settings.SchemaFile)
+		if err != nil {
+			log.Fatalf("❌ не удалось загрузить XSD схему: %v", err)
+		}
+		converterOptions = append(converterOptions, converter.WithSchema(schema))
+		logg.Logf("✅ XSD схема загружена из %s", settings.SchemaFile)
+	}
+
+	converter := converter.NewConverter(converterOptions...)
 	logg.Log("✅ конвертер инциализирован")
 
-	client := client.NewClient()
+	client := client.NewClient(logg, client.WithRetryPolicy(client.RetryPolicy{
+		MaxAttempts: settings.ClientMaxAttempts,
+		BaseDelay:   settings.ClientRetryBaseDelay,
+		MaxDelay:    settings.ClientRetryMaxDelay,
+		Jitter:      settings.ClientRetryJitter,
+	}), client.WithBreaker(client.BreakerSettings{
+		Window:           settings.BreakerWindow,
+		MinRequests:      settings.BreakerMinRequests,
+		FailureRate:      settings.BreakerFailureRate,
+		CoolDown:         settings.BreakerCoolDown,
+		HalfOpenRequests: settings.BreakerHalfOpenRequests,
+	}), client.WithBatchPolicy(client.BatchPolicy{
+		MaxUsers:    settings.BatchMaxUsers,
+		MaxBytes:    settings.BatchMaxBytes,
+		Concurrency: settings.BatchConcurrency,
+	}))
 	logg.Log("✅ клиент инциализирован")
 
 	handler := handler.NewHandler(logg, converter, client)
@@ -42,21 +127,21 @@ func main() {
 
 	// Используем middleware от chi для надежности
 	//r.Use(middleware.Logger)                          // Логирует запросы (от chi в stdout) для тестов
-	r.Use(middleware.Recoverer)                       // Перехватывает паники и возвращает 500
-	r.Use(middleware.Timeout(settings.ClientTimeout)) // Таймаут на весь запрос
+	r.Use(middleware.RequestID)                                                        // ID запроса (X-Request-Id) для ответов об ошибках и логов
+	r.Use(middleware.Recoverer)                                                        // Перехватывает паники и возвращает 500
+	r.Use(appMiddleware.Timeout(logg, settings.ClientTimeout, settings.StreamTimeout)) // Таймаут на весь запрос, 504 в формате problem+json
 
 	// Настройка маршрутов
 	// Группируем роуты, которые требуют авторизации
 	r.Group(func(r chi.Router) {
 		r.Use(appMiddleware.Auth(logg))
+		r.Use(appMiddleware.MaxBodyBytes(logg, settings.MaxBodyBytes, settings.StreamMaxBodyBytes))
 		r.Post("/users", handler.Users)
+		r.Post("/users/xml", handler.UsersXML)
 	})
 
-	// Простой health-check эндпоинт
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Health-check эндпоинт с состоянием предохранителя перед сервером-получателем
+	r.Get("/health", handler.Health)
 
 	logg.Log("✅ маршруты настроены")
 
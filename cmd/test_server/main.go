@@ -1,34 +1,84 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Заголовки идемпотентности (как в internal/client)
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// responseDelay - пауза перед ответом, когда данные уже приняты.
+// Позволяет проверить, что повтор после таймаута клиента не дублирует пользователей.
+var responseDelay = flag.Duration("delay", 0, "пауза перед ответом на /users, например 3s")
+
+// rejectIDs - ID пользователей, которые сервер отклоняет, чтобы проверить частичный прием
+var rejectIDs = flag.String("reject", "", "ID пользователей через запятую, которые сервер отклоняет")
+
+// storedResponse - ответ, запомненный по ключу идемпотентности
+type storedResponse struct {
+	payload [sha256.Size]byte // Хеш тела запроса: тот же ключ с другим телом - ошибка клиента
+	status  int
+	body    []byte
+}
+
+// idempotencyStore - принятые запросы по ключу идемпотентности
+var idempotencyStore = struct {
+	sync.Mutex
+	responses map[string]storedResponse
+}{responses: make(map[string]storedResponse)}
+
 // JSONUser - структура пользователя в JSON формате (как в вашем задании)
 type JSONUser struct {
-	ID       string `json:"id"`
-	FullName string `json:"full_name"`
-	Email    string `json:"email"`
-	AgeGroup string `json:"age_group"`
+	ID           string `json:"id"`
+	FullName     string `json:"full_name"`
+	Email        string `json:"email"`
+	AgeGroup     string `json:"age_group"`
+	AgeGroupCode string `json:"age_group_code,omitempty"`
 }
 
-// ResponseData - структура ответа сервера
+// ResponseData - структура ответа сервера (models.UpstreamResponse в основном сервере)
 type ResponseData struct {
-	Status      string     `json:"status"`
-	Message     string     `json:"message"`
-	ReceivedAt  string     `json:"received_at"`
-	ProcessedBy string     `json:"processed_by"`
-	UserCount   int        `json:"user_count"`
-	Users       []JSONUser `json:"users"`
+	Status      string         `json:"status"` // success, partial или rejected
+	Message     string         `json:"message"`
+	ReceivedAt  string         `json:"received_at"`
+	ProcessedBy string         `json:"processed_by"`
+	UserCount   int            `json:"user_count"` // Число принятых пользователей
+	Users       []JSONUser     `json:"users"`      // Принятые пользователи
+	Rejected    []RejectedUser `json:"rejected"`   // Отклоненные пользователи
+	Results     []UserResult   `json:"results"`    // Результат по каждому пользователю
+}
+
+// UserResult - результат приема одного пользователя
+type UserResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id"`
+	Status string `json:"status"` // accepted или rejected
+	Reason string `json:"reason,omitempty"`
+}
+
+// RejectedUser - пользователь, которого сервер не принял
+type RejectedUser struct {
+	Index  int    `json:"index"` // Номер пользователя в массиве запроса
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
 }
 
 func main() {
+	flag.Parse()
+
 	// Настройка роутов
 	http.HandleFunc("/users", handleUsers)
 	http.HandleFunc("/", handleRoot)
@@ -85,6 +135,33 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("📄 Получено тело запроса (%d байт):\n%s\n", len(body), string(body))
 
+	// Запрос с уже принятым ключом не обрабатывается повторно: возвращаем прежний ответ.
+	// Потоковая отправка передает ключ в трейлере: он доступен после чтения тела
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		key = r.Trailer.Get(idempotencyKeyHeader)
+	}
+	payload := sha256.Sum256(body)
+	if key != "" {
+		idempotencyStore.Lock()
+		stored, ok := idempotencyStore.responses[key]
+		idempotencyStore.Unlock()
+
+		switch {
+		case ok && stored.payload != payload:
+			fmt.Printf("❌ Ключ %s уже использован с другим телом запроса\n", key)
+			http.Error(w, "Idempotency-Key уже использован с другим телом запроса", http.StatusUnprocessableEntity)
+			return
+		case ok:
+			fmt.Printf("♻️ Запрос с ключом %s уже принят, повторяем ответ без обработки\n", key)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(stored.status)
+			w.Write(stored.body)
+			return
+		}
+	}
+
 	// Парсим JSON с пользователями
 	var users []JSONUser
 	if err := json.Unmarshal(body, &users); err != nil {
@@ -102,28 +179,73 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 		fmt.Printf("      • ID: %s\n", user.ID)
 		fmt.Printf("      • Полное имя: %s\n", user.FullName)
 		fmt.Printf("      • Email: %s\n", user.Email)
-		fmt.Printf("      • Возрастная группа: %s\n", user.AgeGroup)
+		fmt.Printf("      • Возрастная группа: %s (%s)\n", user.AgeGroup, user.AgeGroupCode)
 		fmt.Println()
 	}
 
+	// Принимаем пользователей по одному: отклоненные не мешают остальным
+	accepted := []JSONUser{}
+	rejected := []RejectedUser{}
+	results := make([]UserResult, 0, len(users))
+	for i, user := range users {
+		if reason := rejectReason(user); reason != "" {
+			fmt.Printf("🚫 Пользователь %d (ID %q) отклонен: %s\n", i+1, user.ID, reason)
+			rejected = append(rejected, RejectedUser{Index: i, ID: user.ID, Reason: reason})
+			results = append(results, UserResult{Index: i, ID: user.ID, Status: "rejected", Reason: reason})
+			continue
+		}
+		accepted = append(accepted, user)
+		results = append(results, UserResult{Index: i, ID: user.ID, Status: "accepted"})
+	}
+
+	// Если хотя бы один пользователь отклонен, ответ - 207 Multi-Status с результатом по каждому
+	status, statusCode := "success", http.StatusOK
+	switch {
+	case len(accepted) == 0 && len(rejected) > 0:
+		status, statusCode = "rejected", http.StatusMultiStatus
+	case len(rejected) > 0:
+		status, statusCode = "partial", http.StatusMultiStatus
+	}
+
 	// Создаем ответ
 	response := ResponseData{
-		Status:      "success",
-		Message:     fmt.Sprintf("Успешно получено и обработано %d пользователей", len(users)),
+		Status:      status,
+		Message:     fmt.Sprintf("Успешно получено и обработано %d пользователей, отклонено %d", len(accepted), len(rejected)),
 		ReceivedAt:  time.Now().Format("2006-01-02 15:04:05"),
 		ProcessedBy: "Test Server 8081",
-		UserCount:   len(users),
-		Users:       users,
+		UserCount:   len(accepted),
+		Users:       accepted,
+		Rejected:    rejected,
+		Results:     results,
+	}
+
+	var responseBody bytes.Buffer
+	if err := json.NewEncoder(&responseBody).Encode(response); err != nil {
+		fmt.Printf("❌ Ошибка при кодировании ответа: %v\n", err)
+		http.Error(w, "Ошибка при кодировании ответа", http.StatusInternalServerError)
+		return
+	}
+
+	// Данные приняты: запоминаем ответ до паузы, чтобы повтор клиента получил его же
+	if key != "" {
+		idempotencyStore.Lock()
+		idempotencyStore.responses[key] = storedResponse{payload: payload, status: statusCode, body: responseBody.Bytes()}
+		idempotencyStore.Unlock()
+		fmt.Printf("🔑 Ключ идемпотентности: %s\n", key)
+	}
+	if *responseDelay > 0 {
+		fmt.Printf("⏳ Пауза перед ответом: %s\n", *responseDelay)
+		time.Sleep(*responseDelay)
 	}
 
 	// Устанавливаем заголовки ответа
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Processed-By", "Test-Server-8081")
 	w.Header().Set("X-Processing-Time", time.Now().Format(time.RFC3339))
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(statusCode)
 	// Отправляем JSON ответ
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		fmt.Printf("❌ Ошибка при кодировании ответа: %v\n", err)
+	if _, err := w.Write(responseBody.Bytes()); err != nil {
+		fmt.Printf("❌ Ошибка при отправке ответа: %v\n", err)
 		return
 	}
 
@@ -132,6 +254,22 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 	fmt.Println(strings.Repeat("=", 60))
 }
 
+// rejectReason возвращает причину отказа в приеме пользователя; пустая строка - пользователь принят
+func rejectReason(user JSONUser) string {
+	switch {
+	case strings.TrimSpace(user.ID) == "":
+		return "пустой ID"
+	case !strings.Contains(user.Email, "@"):
+		return "некорректный email"
+	}
+	for _, id := range strings.Split(*rejectIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" && id == user.ID {
+			return "ID в списке отклоняемых (-reject)"
+		}
+	}
+	return ""
+}
+
 // handleRoot - обработчик корневого пути для информации
 func handleRoot(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("\n📍 [%s] Запрос на корневой путь от %s\n",
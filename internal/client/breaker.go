@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Состояния предохранителя
+const (
+	BreakerClosed   = "closed"    // Запросы проходят, результаты копятся в окне
+	BreakerOpen     = "open"      // Запросы отклоняются сразу, идет пауза CoolDown
+	BreakerHalfOpen = "half-open" // Проходят только пробные запросы
+)
+
+// BreakerSettings - настройки предохранителя
+type BreakerSettings struct {
+	Window           int           // Число последних попыток, по которым считается доля ошибок
+	MinRequests      int           // Минимум попыток в окне, чтобы предохранитель мог разомкнуться
+	FailureRate      float64       // Доля ошибок в окне, при которой предохранитель размыкается (0..1)
+	CoolDown         time.Duration // Сколько предохранитель остается разомкнутым
+	HalfOpenRequests int           // Успешных пробных попыток, чтобы замкнуться снова
+}
+
+// DefaultBreakerSettings возвращает настройки по умолчанию:
+// половина ошибок среди последних 20 попыток (не меньше 5) размыкает предохранитель на 30 секунд
+func DefaultBreakerSettings() BreakerSettings {
+	return BreakerSettings{
+		Window:           20,
+		MinRequests:      5,
+		FailureRate:      0.5,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// BreakerState - снимок состояния предохранителя для /health
+type BreakerState struct {
+	State     string     `json:"state"`               // closed, open или half-open
+	Requests  int        `json:"requests"`            // Попыток в окне
+	Failures  int        `json:"failures"`            // Ошибок в окне
+	OpenUntil *time.Time `json:"openUntil,omitempty"` // Конец паузы разомкнутого предохранителя
+}
+
+// OpenError - попытка отклонена разомкнутым предохранителем
+type OpenError struct {
+	RetryAfter time.Duration // Через сколько предохранитель пропустит пробный запрос
+}
+
+func (e *OpenError) Error() string {
+	if e.RetryAfter <= 0 {
+		return models.ErrCircuitOpen.Error()
+	}
+	return fmt.Sprintf("%v, повтор через %s", models.ErrCircuitOpen, e.RetryAfter.Round(time.Second))
+}
+
+func (e *OpenError) Unwrap() error {
+	return models.ErrCircuitOpen
+}
+
+// outcome - результат попытки для предохранителя
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota // Сервер ответил
+	outcomeFailure                // Сервер недоступен или ответил 5xx, 408, 429
+	outcomeIgnored                // Попытка прервана не по вине сервера
+)
+
+// Breaker - предохранитель (circuit breaker) перед сервером-получателем.
+// Когда доля ошибок в окне последних попыток достигает FailureRate, предохранитель
+// размыкается и попытки отклоняются сразу, без ожидания таймаута. После CoolDown
+// пропускаются пробные попытки: успех замыкает предохранитель, ошибка размыкает снова.
+type Breaker struct {
+	settings BreakerSettings
+	now      func() time.Time
+
+	mu         sync.Mutex
+	state      string
+	generation uint64 // Меняется при смене состояния: результаты старых попыток не учитываются
+	outcomes   []bool // Кольцевой буфер окна: true - ошибка
+	next       int
+	requests   int
+	failures   int
+	openedAt   time.Time
+	probes     int // Пробные попытки в полуразомкнутом состоянии
+	succeeded  int // Успешные пробные попытки
+}
+
+// NewBreaker создает замкнутый предохранитель
+func NewBreaker(settings BreakerSettings) *Breaker {
+	settings.Window = max(settings.Window, 1)
+	settings.HalfOpenRequests = max(settings.HalfOpenRequests, 1)
+	return &Breaker{
+		settings: settings,
+		now:      time.Now,
+		state:    BreakerClosed,
+		outcomes: make([]bool, settings.Window),
+	}
+}
+
+// State возвращает снимок состояния предохранителя
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := BreakerState{State: b.state, Requests: b.requests, Failures: b.failures}
+	if b.state == BreakerOpen {
+		openUntil := b.openedAt.Add(b.settings.CoolDown)
+		if b.now().Before(openUntil) {
+			state.OpenUntil = &openUntil
+		} else {
+			// Пауза кончилась: следующая попытка будет пробной
+			state.State = BreakerHalfOpen
+		}
+	}
+	return state
+}
+
+// allow решает, пропустить ли попытку. Возвращает поколение, которое передается в done.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		wait := b.openedAt.Add(b.settings.CoolDown).Sub(b.now())
+		if wait > 0 {
+			return 0, &OpenError{RetryAfter: wait}
+		}
+		b.setState(BreakerHalfOpen)
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probes >= b.settings.HalfOpenRequests {
+			// Пробные попытки уже отправлены, ждем их результата
+			return 0, &OpenError{}
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// done учитывает результат попытки и возвращает состояния до и после него
+func (b *Breaker) done(generation uint64, result outcome) (from, to string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from = b.state
+	if generation != b.generation {
+		return from, b.state
+	}
+
+	switch b.state {
+	case BreakerHalfOpen:
+		switch result {
+		case outcomeFailure:
+			b.setState(BreakerOpen)
+		case outcomeSuccess:
+			b.succeeded++
+			if b.succeeded >= b.settings.HalfOpenRequests {
+				b.setState(BreakerClosed)
+			}
+		case outcomeIgnored:
+			b.probes--
+		}
+	case BreakerClosed:
+		if result == outcomeIgnored {
+			break
+		}
+		b.push(result == outcomeFailure)
+		if b.requests >= b.settings.MinRequests && float64(b.failures) >= b.settings.FailureRate*float64(b.requests) && b.failures > 0 {
+			b.setState(BreakerOpen)
+		}
+	}
+	return from, b.state
+}
+
+// push добавляет результат в окно, вытесняя самый старый
+func (b *Breaker) push(failed bool) {
+	if b.requests == len(b.outcomes) {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+	} else {
+		b.requests++
+	}
+	b.outcomes[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.outcomes)
+}
+
+// setState переключает предохранитель и начинает новое поколение попыток
+func (b *Breaker) setState(state string) {
+	b.state = state
+	b.generation++
+	b.probes, b.succeeded = 0, 0
+	switch state {
+	case BreakerOpen:
+		b.openedAt = b.now()
+	case BreakerClosed:
+		clear(b.outcomes)
+		b.next, b.requests, b.failures = 0, 0, 0
+	}
+}
+
+// classify определяет результат попытки для предохранителя.
+// Ответы 4xx значат, что сервер работает; отмена запроса клиентом не учитывается.
+func classify(err error, canceled bool) outcome {
+	if err == nil {
+		return outcomeSuccess
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= 500 || statusErr.Temporary() {
+			return outcomeFailure
+		}
+		return outcomeSuccess
+	}
+
+	var sendErr *sendError
+	if errors.As(err, &sendErr) && !canceled {
+		return outcomeFailure
+	}
+	return outcomeIgnored
+}
+
+// guard пропускает попытку через предохранитель клиента и сообщает ему результат
+func (c *Client) guard(op string, attempt attemptFunc) attemptFunc {
+	if c.breaker == nil {
+		return attempt
+	}
+	return func(ctx context.Context) ([]byte, error) {
+		generation, err := c.breaker.allow()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := attempt(ctx)
+		if from, to := c.breaker.done(generation, classify(err, ctx.Err() != nil)); from != to {
+			c.logf("🔌 %s: предохранитель %s -> %s", op, from, to)
+		}
+		return body, err
+	}
+}
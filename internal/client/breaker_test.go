@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBreaker - предохранитель с управляемыми часами
+func testBreaker(settings BreakerSettings) (*Breaker, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBreaker(settings)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+// attempt пропускает попытку с результатом result через предохранитель
+func attempt(t *testing.T, b *Breaker, result outcome) {
+	t.Helper()
+	generation, err := b.allow()
+	require.NoError(t, err)
+	b.done(generation, result)
+}
+
+func TestBreaker_Opens(t *testing.T) {
+	b, now := testBreaker(BreakerSettings{Window: 10, MinRequests: 4, FailureRate: 0.5, CoolDown: time.Minute})
+
+	attempt(t, b, outcomeFailure)
+	attempt(t, b, outcomeFailure)
+	attempt(t, b, outcomeFailure)
+	assert.Equal(t, BreakerClosed, b.State().State, "меньше MinRequests попыток")
+
+	attempt(t, b, outcomeSuccess)
+	state := b.State()
+	assert.Equal(t, BreakerOpen, state.State)
+	require.NotNil(t, state.OpenUntil)
+	assert.Equal(t, now.Add(time.Minute), *state.OpenUntil)
+
+	_, err := b.allow()
+	var openErr *OpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, time.Minute, openErr.RetryAfter)
+	assert.ErrorIs(t, err, models.ErrCircuitOpen)
+}
+
+func TestBreaker_Window(t *testing.T) {
+	b, _ := testBreaker(BreakerSettings{Window: 4, MinRequests: 4, FailureRate: 0.75, CoolDown: time.Minute})
+
+	// Старые ошибки вытесняются из окна успешными попытками
+	attempt(t, b, outcomeFailure)
+	attempt(t, b, outcomeFailure)
+	for range 4 {
+		attempt(t, b, outcomeSuccess)
+	}
+	attempt(t, b, outcomeFailure)
+	attempt(t, b, outcomeFailure)
+	state := b.State()
+	assert.Equal(t, BreakerClosed, state.State)
+	assert.Equal(t, 4, state.Requests)
+	assert.Equal(t, 2, state.Failures)
+
+	attempt(t, b, outcomeFailure)
+	assert.Equal(t, BreakerOpen, b.State().State)
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		probe    outcome
+		expected string
+	}{
+		{name: "пробная попытка успешна", probe: outcomeSuccess, expected: BreakerClosed},
+		{name: "пробная попытка с ошибкой", probe: outcomeFailure, expected: BreakerOpen},
+		{name: "пробная попытка отменена", probe: outcomeIgnored, expected: BreakerHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, now := testBreaker(BreakerSettings{Window: 2, MinRequests: 2, FailureRate: 1, CoolDown: time.Minute})
+			attempt(t, b, outcomeFailure)
+			attempt(t, b, outcomeFailure)
+			require.Equal(t, BreakerOpen, b.State().State)
+
+			*now = now.Add(time.Minute)
+			assert.Equal(t, BreakerHalfOpen, b.State().State, "пауза кончилась")
+
+			generation, err := b.allow()
+			require.NoError(t, err)
+			_, err = b.allow()
+			assert.ErrorIs(t, err, models.ErrCircuitOpen, "пробная попытка уже идет")
+
+			b.done(generation, tt.probe)
+			assert.Equal(t, tt.expected, b.State().State)
+		})
+	}
+}
+
+func TestBreaker_StaleResult(t *testing.T) {
+	b, _ := testBreaker(BreakerSettings{Window: 2, MinRequests: 1, FailureRate: 1, CoolDown: time.Minute})
+
+	stale, err := b.allow()
+	require.NoError(t, err)
+	attempt(t, b, outcomeFailure)
+	require.Equal(t, BreakerOpen, b.State().State)
+
+	// Попытка, начатая до размыкания, не влияет на новое состояние
+	from, to := b.done(stale, outcomeSuccess)
+	assert.Equal(t, BreakerOpen, from)
+	assert.Equal(t, BreakerOpen, to)
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, outcomeSuccess, classify(nil, false))
+	assert.Equal(t, outcomeSuccess, classify(&StatusError{StatusCode: http.StatusBadRequest}, false))
+	assert.Equal(t, outcomeFailure, classify(&StatusError{StatusCode: http.StatusInternalServerError}, false))
+	assert.Equal(t, outcomeFailure, classify(&StatusError{StatusCode: http.StatusTooManyRequests}, false))
+	assert.Equal(t, outcomeFailure, classify(&sendError{err: errors.New("connection refused")}, false))
+	assert.Equal(t, outcomeIgnored, classify(&sendError{err: context.Canceled}, true))
+	assert.Equal(t, outcomeIgnored, classify(models.ErrNoUsers, false))
+}
+
+func TestClient_SendUsers_Breaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:     server.URL,
+		client:  &http.Client{Timeout: time.Second},
+		retry:   RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond},
+		breaker: NewBreaker(BreakerSettings{Window: 3, MinRequests: 3, FailureRate: 1, CoolDown: time.Minute}),
+	}
+
+	_, err := client.SendUsers(context.Background(), retryUsers)
+
+	// Третья неудачная попытка размыкает предохранитель, четвертая отклоняется без запроса
+	assert.ErrorIs(t, err, models.ErrCircuitOpen)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, BreakerOpen, client.BreakerState().State)
+
+	_, err = client.SendUsersStream(context.Background(), produceUsers(1))
+	assert.ErrorIs(t, err, models.ErrCircuitOpen)
+	assert.Equal(t, int32(3), calls.Load())
+}
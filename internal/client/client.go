@@ -3,19 +3,65 @@ package client
 import (
 	"net/http"
 
+	"github.com/NarthurN/GoXML_JSON/pkg/logger"
 	"github.com/NarthurN/GoXML_JSON/settings"
 )
 
 type Client struct {
 	URL    string
-	client *http.Client
+	client *http.Client   // Timeout - таймаут одной попытки отправки
+	logger *logger.Logger // Лог попыток отправки (nil - без лога)
+	retry  RetryPolicy    // Повтор запросов при временных сбоях
+
+	breaker *Breaker    // Предохранитель перед сервером-получателем (nil - без него)
+	batch   BatchPolicy // Разбиение пользователей на пакеты
+}
+
+// Option - функциональная опция для настройки клиента
+type Option func(*Client)
+
+// WithRetryPolicy задает политику повтора запросов
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithBreaker ставит перед сервером-получателем предохранитель с настройками settings
+func WithBreaker(settings BreakerSettings) Option {
+	return func(c *Client) {
+		c.breaker = NewBreaker(settings)
+	}
 }
 
-func NewClient() *Client {
-	return &Client{
+func NewClient(logger *logger.Logger, opts ...Option) *Client {
+	c := &Client{
 		URL: settings.ClientURL,
 		client: &http.Client{
-			Timeout: settings.ClientTimeout,
+			Timeout: settings.ClientAttemptTimeout,
 		},
+		logger: logger,
+		retry:  DefaultRetryPolicy(),
+
+		breaker: NewBreaker(DefaultBreakerSettings()),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// logf пишет в лог клиента, если он задан
+func (c *Client) logf(format string, args ...any) {
+	if c.logger != nil {
+		c.logger.Logf(format, args...)
+	}
+}
+
+// BreakerState возвращает состояние предохранителя; без предохранителя он всегда замкнут
+func (c *Client) BreakerState() BreakerState {
+	if c.breaker == nil {
+		return BreakerState{State: BreakerClosed}
 	}
+	return c.breaker.State()
 }
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Заголовки идемпотентности запросов к серверу-получателю
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"     // Ключ запроса
+	IdempotentReplayedHeader = "Idempotent-Replayed" // "true" - сервер уже принимал запрос с этим ключом и повторил ответ
+)
+
+// idempotencyKey возвращает ключ идемпотентности пакета: хеш ID исходного запроса и тела пакета.
+// Ключ одинаков для всех повторов пакета, поэтому сервер-получатель, принявший данные
+// до таймаута, не запишет их второй раз. Без ID запроса ключ зависит только от тела.
+func idempotencyKey(ctx context.Context, payload []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(middleware.GetReqID(ctx)))
+	hash.Write([]byte{0})
+	hash.Write(payload)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// streamKey вычисляет ключ идемпотентности потоковой отправки по мере записи тела:
+// тело заранее неизвестно, поэтому ключ уходит в трейлере запроса, после тела.
+// Ключ тот же, что idempotencyKey от ID запроса и всего тела.
+type streamKey struct {
+	hash    hash.Hash
+	trailer http.Header
+}
+
+// newStreamKey возвращает ключ потоковой отправки или nil без ID запроса:
+// ключ от одного тела не отличал бы повтор запроса от нового запроса с тем же документом
+func newStreamKey(ctx context.Context) *streamKey {
+	id := middleware.GetReqID(ctx)
+	if id == "" {
+		return nil
+	}
+	k := &streamKey{hash: sha256.New(), trailer: http.Header{IdempotencyKeyHeader: nil}}
+	k.hash.Write([]byte(id))
+	k.hash.Write([]byte{0})
+	return k
+}
+
+// finish записывает ключ в трейлер; вызывается, когда тело записано целиком
+func (k *streamKey) finish() {
+	k.trailer.Set(IdempotencyKeyHeader, hex.EncodeToString(k.hash.Sum(nil)))
+}
+
+// key возвращает ключ, отправленный в трейлере ("" - не отправлен)
+func (k *streamKey) key() string {
+	if k == nil {
+		return ""
+	}
+	return k.trailer.Get(IdempotencyKeyHeader)
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withReqID возвращает контекст с идентификатором запроса, как после middleware.RequestID
+func withReqID(id string) context.Context {
+	return context.WithValue(context.Background(), middleware.RequestIDKey, id)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	payload := []byte(`[{"id":"1"}]`)
+	key := idempotencyKey(withReqID("req-1"), payload)
+
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, idempotencyKey(withReqID("req-1"), payload), "ключ детерминирован")
+	assert.NotEqual(t, key, idempotencyKey(withReqID("req-2"), payload), "другой запрос")
+	assert.NotEqual(t, key, idempotencyKey(withReqID("req-1"), []byte(`[{"id":"2"}]`)), "другое тело")
+	assert.NotEqual(t, idempotencyKey(withReqID("a"), []byte("bc")), idempotencyKey(withReqID("ab"), []byte("c")), "граница между ID и телом")
+}
+
+func TestClient_SendUsers_IdempotencyKeyAcrossRetries(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		n := len(keys)
+		mu.Unlock()
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.Write([]byte(`{"status": "success", "user_count": 1}`))
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: fastRetry}
+
+	_, err := client.SendUsers(withReqID("req-1"), retryUsers)
+
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1], "повтор отправляется с тем же ключом")
+	assert.Equal(t, keys[0], keys[2])
+}
+
+func TestClient_SendUsersBatches_IdempotencyKeys(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys = map[string]int{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys[r.Header.Get(IdempotencyKeyHeader)]++
+		mu.Unlock()
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(users))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 2},
+	}
+
+	delivery, err := client.SendUsersBatches(withReqID("req-1"), batchUsers(5))
+
+	require.NoError(t, err)
+	require.Len(t, delivery.Batches, 3)
+	assert.Len(t, keys, 3, "у каждого пакета свой ключ")
+	for _, batch := range delivery.Batches {
+		assert.Equal(t, 1, keys[batch.Key])
+	}
+}
+
+func TestClient_SendUsersStream_IdempotencyKey(t *testing.T) {
+	type request struct {
+		header string
+		key    string
+		body   []byte
+	}
+	var (
+		mu       sync.Mutex
+		requests []request
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		// Трейлер доступен только после чтения тела
+		mu.Lock()
+		requests = append(requests, request{
+			header: r.Header.Get(IdempotencyKeyHeader),
+			key:    r.Trailer.Get(IdempotencyKeyHeader),
+			body:   body,
+		})
+		mu.Unlock()
+		var users []models.JSONUser
+		require.NoError(t, json.Unmarshal(body, &users))
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(users))
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}}
+
+	first, err := client.SendUsersStream(withReqID("req-1"), produceUsers(3))
+	require.NoError(t, err)
+	again, err := client.SendUsersStream(withReqID("req-1"), produceUsers(3))
+	require.NoError(t, err)
+	otherDocument, err := client.SendUsersStream(withReqID("req-1"), produceUsers(4))
+	require.NoError(t, err)
+	otherRequest, err := client.SendUsersStream(withReqID("req-2"), produceUsers(3))
+	require.NoError(t, err)
+	_, err = client.SendUsersStream(context.Background(), produceUsers(3))
+	require.NoError(t, err)
+
+	require.Len(t, requests, 5)
+	for _, r := range requests {
+		assert.Empty(t, r.header, "тело заранее неизвестно: ключ передается в трейлере")
+	}
+	assert.Equal(t, idempotencyKey(withReqID("req-1"), requests[0].body), requests[0].key, "ключ - хеш ID запроса и тела")
+	assert.Equal(t, requests[0].key, first.Batches[0].Key)
+	assert.Equal(t, requests[0].key, requests[1].key, "повтор того же документа")
+	assert.Equal(t, requests[1].key, again.Batches[0].Key)
+	assert.NotEqual(t, requests[0].key, requests[2].key, "другой документ с тем же ID запроса")
+	assert.Equal(t, requests[2].key, otherDocument.Batches[0].Key)
+	assert.NotEqual(t, requests[0].key, requests[3].key, "другой запрос")
+	assert.Equal(t, requests[3].key, otherRequest.Batches[0].Key)
+	assert.Empty(t, requests[4].key, "без ID запроса ключ не передается")
+}
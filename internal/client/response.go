@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// maxResponseSnippet - сколько байт ответа попадает в текст ошибки
+const maxResponseSnippet = 200
+
+// parseResponse разбирает ответ сервера-получателя на sent отправленных пользователей.
+// Ответ должен быть JSON объектом с известным статусом, а принятые и отклоненные
+// пользователи вместе - давать sent. Если в ответе есть результаты по каждому пользователю,
+// user_count, rejected и пустой status вычисляются по ним.
+// Пустое тело (например, 202 Accepted или 204 No Content) означает, что приняты все sent:
+// разбирается только ответ со статусом 2xx, а отказ по каждому пользователю сервер сообщил бы в теле.
+// По той же причине ответ без user_count означает, что приняты все, кроме перечисленных в rejected;
+// ошибкой считается только число, которое противоречит отправленному.
+// Ошибка оборачивает models.ErrInvalidUpstreamResponse.
+func parseResponse(body []byte, sent int) (*models.UpstreamResponse, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &models.UpstreamResponse{Status: models.UpstreamStatusSuccess, UserCount: sent}, nil
+	}
+
+	// user_count читается отдельно, чтобы отличить отсутствующее поле от нуля
+	var parsed struct {
+		models.UpstreamResponse
+		UserCount *int `json:"user_count"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %w, ответ: %q", models.ErrInvalidUpstreamResponse, err, snippet(body))
+	}
+	response := parsed.UpstreamResponse
+	if parsed.UserCount != nil {
+		response.UserCount = *parsed.UserCount
+	} else {
+		response.UserCount = sent - len(response.Rejected)
+	}
+	if len(response.Results) > 0 {
+		if err := applyResults(&response, sent); err != nil {
+			return nil, fmt.Errorf("%w: %w", models.ErrInvalidUpstreamResponse, err)
+		}
+	}
+
+	switch response.Status {
+	case models.UpstreamStatusSuccess, models.UpstreamStatusPartial, models.UpstreamStatusRejected:
+	default:
+		return nil, fmt.Errorf("%w: неизвестный статус %q", models.ErrInvalidUpstreamResponse, response.Status)
+	}
+
+	if response.UserCount < 0 || response.UserCount+len(response.Rejected) != sent {
+		return nil, fmt.Errorf("%w: принято %d и отклонено %d пользователей из %d отправленных",
+			models.ErrInvalidUpstreamResponse, response.UserCount, len(response.Rejected), sent)
+	}
+
+	seen := make(map[int]bool, len(response.Rejected))
+	for _, rejected := range response.Rejected {
+		if rejected.Index < 0 || rejected.Index >= sent || seen[rejected.Index] {
+			return nil, fmt.Errorf("%w: некорректный номер отклоненного пользователя %d",
+				models.ErrInvalidUpstreamResponse, rejected.Index)
+		}
+		seen[rejected.Index] = true
+	}
+
+	return &response, nil
+}
+
+// applyResults заполняет user_count, rejected и status ответа по результатам для каждого пользователя.
+// Результат должен быть ровно один на каждого отправленного пользователя.
+func applyResults(response *models.UpstreamResponse, sent int) error {
+	if len(response.Results) != sent {
+		return fmt.Errorf("результатов %d, отправлено пользователей %d", len(response.Results), sent)
+	}
+
+	seen := make(map[int]bool, sent)
+	accepted := 0
+	var rejected []models.RejectedUser
+	for _, result := range response.Results {
+		if result.Index < 0 || result.Index >= sent || seen[result.Index] {
+			return fmt.Errorf("некорректный номер пользователя в результатах %d", result.Index)
+		}
+		seen[result.Index] = true
+
+		switch result.Status {
+		case models.UserStatusAccepted:
+			accepted++
+		case models.UserStatusRejected:
+			rejected = append(rejected, models.RejectedUser{Index: result.Index, ID: result.ID, Reason: result.Reason})
+		default:
+			return fmt.Errorf("неизвестный статус пользователя %q в результатах", result.Status)
+		}
+	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Index < rejected[j].Index })
+
+	response.UserCount = accepted
+	response.Rejected = rejected
+	if response.Status == "" {
+		switch {
+		case len(rejected) == 0:
+			response.Status = models.UpstreamStatusSuccess
+		case accepted == 0:
+			response.Status = models.UpstreamStatusRejected
+		default:
+			response.Status = models.UpstreamStatusPartial
+		}
+	}
+	return nil
+}
+
+// snippet возвращает начало ответа для текста ошибки
+func snippet(body []byte) string {
+	if len(body) > maxResponseSnippet {
+		return string(body[:maxResponseSnippet]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		sent          int
+		expectedError string
+	}{
+		{name: "все приняты", body: `{"status": "success", "user_count": 2}`, sent: 2},
+		{name: "часть отклонена", body: `{"status": "partial", "user_count": 1, "rejected": [{"index": 1, "id": "2", "reason": "нет email"}]}`, sent: 2},
+		{name: "все отклонены", body: `{"status": "rejected", "user_count": 0, "rejected": [{"index": 0, "id": "1", "reason": "нет email"}]}`, sent: 1},
+		{name: "не JSON", body: `<html>Bad Gateway</html>`, sent: 1, expectedError: "Bad Gateway"},
+		{name: "пустой ответ", body: ``, sent: 3},
+		{name: "ответ из пробелов", body: " \n", sent: 1},
+		{name: "нет user_count", body: `{"status": "success"}`, sent: 3},
+		{name: "нет user_count, часть отклонена", body: `{"status": "partial", "rejected": [{"index": 1, "id": "2", "reason": "нет email"}]}`, sent: 3},
+		{name: "user_count равен нулю", body: `{"status": "success", "user_count": 0}`, sent: 2, expectedError: "принято 0 и отклонено 0 пользователей из 2"},
+		{name: "нет user_count, отклонено больше отправленных", body: `{"status": "rejected", "rejected": [{"index": 0}, {"index": 1}]}`, sent: 1, expectedError: "принято -1 и отклонено 2 пользователей из 1"},
+		{name: "неизвестный статус", body: `{"status": "ok", "user_count": 1}`, sent: 1, expectedError: `неизвестный статус "ok"`},
+		{name: "число не сходится", body: `{"status": "success", "user_count": 1}`, sent: 2, expectedError: "принято 1 и отклонено 0 пользователей из 2"},
+		{name: "номер вне пакета", body: `{"status": "partial", "user_count": 1, "rejected": [{"index": 2, "id": "3"}]}`, sent: 2, expectedError: "номер отклоненного пользователя 2"},
+		{name: "номер повторяется", body: `{"status": "rejected", "user_count": 0, "rejected": [{"index": 0}, {"index": 0}]}`, sent: 2, expectedError: "номер отклоненного пользователя 0"},
+		{name: "результаты по пользователям", body: `{"results": [{"index": 1, "id": "2", "status": "rejected", "reason": "нет email"}, {"index": 0, "id": "1", "status": "accepted"}]}`, sent: 2},
+		{name: "результатов меньше", body: `{"results": [{"index": 0, "status": "accepted"}]}`, sent: 2, expectedError: "результатов 1, отправлено пользователей 2"},
+		{name: "результат повторяется", body: `{"results": [{"index": 0, "status": "accepted"}, {"index": 0, "status": "accepted"}]}`, sent: 2, expectedError: "некорректный номер пользователя в результатах 0"},
+		{name: "неизвестный статус результата", body: `{"results": [{"index": 0, "status": "ok"}]}`, sent: 1, expectedError: `неизвестный статус пользователя "ok"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := parseResponse([]byte(tt.body), tt.sent)
+
+			if tt.expectedError != "" {
+				assert.ErrorIs(t, err, models.ErrInvalidUpstreamResponse)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Nil(t, response)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.sent, response.UserCount+len(response.Rejected))
+		})
+	}
+}
+
+func TestParseResponse_Results(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		expected string
+	}{
+		{name: "все приняты", statuses: []string{"accepted", "accepted"}, expected: models.UpstreamStatusSuccess},
+		{name: "часть отклонена", statuses: []string{"rejected", "accepted"}, expected: models.UpstreamStatusPartial},
+		{name: "все отклонены", statuses: []string{"rejected", "rejected"}, expected: models.UpstreamStatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response models.UpstreamResponse
+			for i, status := range tt.statuses {
+				response.Results = append(response.Results, models.UserResult{Index: i, ID: fmt.Sprint(i + 1), Status: status, Reason: "причина"})
+			}
+
+			parsed, err := parseResponse(mustMarshal(t, response), len(tt.statuses))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, parsed.Status, "статус вычисляется по результатам")
+			assert.Equal(t, len(tt.statuses), parsed.UserCount+len(parsed.Rejected))
+			for _, rejected := range parsed.Rejected {
+				assert.Equal(t, models.UserStatusRejected, tt.statuses[rejected.Index])
+			}
+		})
+	}
+}
+
+func TestParseResponse_LongBody(t *testing.T) {
+	_, err := parseResponse([]byte(strings.Repeat("x", 1000)), 1)
+
+	require.Error(t, err)
+	assert.Less(t, len(err.Error()), 400, "в ошибку попадает только начало ответа")
+}
+
+func TestClient_SendUsersBatches_Rejected(t *testing.T) {
+	// Сервер отклоняет пользователей без email
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+
+		response := models.UpstreamResponse{Status: models.UpstreamStatusSuccess}
+		for i, user := range users {
+			if user.Email == "" {
+				response.Rejected = append(response.Rejected, models.RejectedUser{Index: i, ID: user.ID, Reason: "нет email"})
+			} else {
+				response.UserCount++
+			}
+		}
+		if len(response.Rejected) > 0 {
+			response.Status = models.UpstreamStatusPartial
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	// Записи 1, 3 и 5 документа не прошли валидацию: номера пользователей и записей расходятся
+	users := batchUsers(5)
+	for i := range users {
+		users[i].Index = []int{0, 2, 4, 6, 7}[i]
+	}
+	users[1].Email = ""
+	users[4].Email = ""
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 2},
+	}
+
+	delivery, err := client.SendUsersBatches(context.Background(), users)
+
+	require.NoError(t, err)
+	assert.NoError(t, delivery.Err())
+	assert.Equal(t, 3, delivery.Delivered)
+	assert.Equal(t, 2, delivery.Refused)
+	assert.Equal(t, 0, delivery.Failed)
+	assert.Equal(t, 1, delivery.Batches[0].Refused)
+	assert.Equal(t, 2, delivery.Batches[1].Accepted)
+
+	// Номера - записи документа, а не пользователя в пакете
+	report := delivery.Report(users)
+	assert.Equal(t, []models.DeliveredUser{{Index: 0, ID: "1"}, {Index: 4, ID: "3"}, {Index: 6, ID: "4"}}, report.Delivered)
+	assert.Equal(t, []models.RejectedUser{
+		{Index: 2, ID: "2", Reason: "нет email"},
+		{Index: 7, ID: "5", Reason: "нет email"},
+	}, report.Rejected)
+	assert.Empty(t, report.Failed)
+}
+
+func TestClient_SendUsersBatches_MultiStatus(t *testing.T) {
+	// Сервер отвечает 207 с результатом по каждому пользователю и отклоняет четные ID
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+
+		var response models.UpstreamResponse
+		for i, user := range users {
+			result := models.UserResult{Index: i, ID: user.ID, Status: models.UserStatusAccepted}
+			if id, _ := strconv.Atoi(user.ID); id%2 == 0 {
+				result.Status, result.Reason = models.UserStatusRejected, "ID уже занят"
+			}
+			response.Results = append(response.Results, result)
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	users := batchUsers(5)
+	for i := range users {
+		users[i].Index = i + 10
+	}
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 3},
+	}
+
+	delivery, err := client.SendUsersBatches(context.Background(), users)
+
+	require.NoError(t, err)
+	assert.NoError(t, delivery.Err(), "207 - не ошибка")
+	assert.Equal(t, 3, delivery.Delivered)
+	assert.Equal(t, 2, delivery.Refused)
+	assert.Equal(t, models.UpstreamStatusPartial, delivery.Batches[0].Response.Status)
+
+	report := delivery.Report(users)
+	assert.Equal(t, []models.DeliveredUser{{Index: 10, ID: "1"}, {Index: 12, ID: "3"}, {Index: 14, ID: "5"}}, report.Delivered)
+	assert.Equal(t, []models.RejectedUser{
+		{Index: 11, ID: "2", Reason: "ID уже занят"},
+		{Index: 13, ID: "4", Reason: "ID уже занят"},
+	}, report.Rejected)
+}
+
+func TestClient_SendUsersBatches_InvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}}
+
+	delivery, err := client.SendUsersBatches(context.Background(), batchUsers(2))
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivery.Delivered)
+	assert.Equal(t, 2, delivery.Failed)
+	assert.ErrorIs(t, delivery.Err(), models.ErrInvalidUpstreamResponse)
+
+	report := delivery.Report(batchUsers(2))
+	assert.Empty(t, report.Delivered)
+	require.Len(t, report.Failed, 2)
+	assert.Contains(t, report.Failed[0].Reason, models.ErrInvalidUpstreamResponse.Error())
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy - повтор запроса к серверу-получателю при временных сбоях.
+// Повторяются только сетевые ошибки и статусы, при которых сервер не обработал запрос.
+// Нулевое значение означает одну попытку без повторов.
+type RetryPolicy struct {
+	MaxAttempts int           // Число попыток, включая первую
+	BaseDelay   time.Duration // Пауза перед первым повтором, дальше удваивается
+	MaxDelay    time.Duration // Наибольшая пауза; Retry-After длиннее нее прекращает повторы
+	Jitter      float64       // Доля паузы, на которую она случайно сокращается (0..1)
+}
+
+// DefaultRetryPolicy возвращает политику по умолчанию: 3 попытки с паузами около 200 и 400 мс
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// retryableStatuses - статусы, после которых запрос безопасно повторить:
+// сервер его не обработал или просит прийти позже
+var retryableStatuses = map[int]bool{
+	http.StatusRequestTimeout:     true,
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// StatusError - сервер-получатель ответил статусом, отличным от 200
+type StatusError struct {
+	StatusCode int           // Код статуса
+	Status     string        // Статус целиком, например "503 Service Unavailable"
+	Body       []byte        // Тело ответа
+	RetryAfter time.Duration // Пауза из заголовка Retry-After (0 - не указана)
+}
+
+func newStatusError(resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("получен неверный статус: %s - %s", e.Status, string(e.Body))
+}
+
+// Temporary сообщает, что запрос можно повторить
+func (e *StatusError) Temporary() bool {
+	return retryableStatuses[e.StatusCode]
+}
+
+// parseRetryAfter разбирает Retry-After: число секунд или HTTP дату
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// backoff возвращает паузу перед повтором после неудачной попытки attempt (начиная с 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(delay))
+	}
+	return delay
+}
+
+// attemptFunc выполняет одну попытку запроса
+type attemptFunc func(ctx context.Context) ([]byte, error)
+
+// withRetry выполняет attempt, повторяя его по политике клиента.
+// Повторы прекращаются, если пауза не укладывается в дедлайн ctx.
+func (c *Client) withRetry(ctx context.Context, op string, attempt attemptFunc) ([]byte, error) {
+	attempts := max(c.retry.MaxAttempts, 1)
+	for n := 1; ; n++ {
+		started := time.Now()
+		body, err := attempt(ctx)
+		if err == nil {
+			c.logf("✅ %s: попытка %d из %d успешна за %s", op, n, attempts, time.Since(started))
+			return body, nil
+		}
+
+		delay, retry := c.retryDelay(ctx, err, n)
+		if !retry || n == attempts {
+			c.logf("❌ %s: попытка %d из %d не удалась, повторов не будет: %v", op, n, attempts, err)
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			c.logf("❌ %s: попытка %d из %d не удалась, пауза %s не укладывается в дедлайн: %v", op, n, attempts, delay, err)
+			return nil, err
+		}
+		c.logf("⚠️ %s: попытка %d из %d не удалась, повтор через %s: %v", op, n, attempts, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w (повтор отменен: %w)", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay решает, повторять ли запрос после ошибки err попытки attempt, и возвращает паузу.
+// Retry-After сервера важнее собственной паузы, но не дольше MaxDelay.
+func (c *Client) retryDelay(ctx context.Context, err error, attempt int) (time.Duration, bool) {
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
+	delay := c.retry.backoff(attempt)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if !statusErr.Temporary() {
+			return 0, false
+		}
+		if statusErr.RetryAfter > 0 {
+			if c.retry.MaxDelay > 0 && statusErr.RetryAfter > c.retry.MaxDelay {
+				return 0, false
+			}
+			delay = statusErr.RetryAfter
+		}
+		return delay, true
+	}
+
+	// Ошибки подготовки запроса и чтения ответа не исправятся повтором
+	var sendErr *sendError
+	return delay, errors.As(err, &sendErr)
+}
+
+// sendError - запрос не дошел до сервера или ответ не получен: сетевая ошибка или таймаут попытки
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string {
+	return "ошибка при отправке пользователей на сервер: " + e.err.Error()
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
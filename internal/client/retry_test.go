@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry - политика с короткими паузами для тестов
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+
+var retryUsers = []models.JSONUser{{ID: "1", FullName: "Иван Иванов", Email: "ivan@example.com", AgeGroup: "от 25 до 35"}}
+
+// flakyServer отвечает статусами statuses по очереди, затем 200
+func flakyServer(t *testing.T, calls *atomic.Int32, headers http.Header, statuses ...int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			for name, values := range headers {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "success", "user_count": 1}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_SendUsers_Retry(t *testing.T) {
+	tests := []struct {
+		name          string
+		statuses      []int
+		expectedCalls int32
+		expectedError bool
+	}{
+		{name: "503 и затем успех", statuses: []int{503, 503}, expectedCalls: 3},
+		{name: "429 и 502", statuses: []int{429, 502}, expectedCalls: 3},
+		{name: "попытки закончились", statuses: []int{503, 503, 503}, expectedCalls: 3, expectedError: true},
+		{name: "400 не повторяется", statuses: []int{400}, expectedCalls: 1, expectedError: true},
+		{name: "500 не повторяется", statuses: []int{500}, expectedCalls: 1, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := flakyServer(t, &calls, nil, tt.statuses...)
+			client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: fastRetry}
+
+			result, err := client.SendUsers(context.Background(), retryUsers)
+
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+			if tt.expectedError {
+				var statusErr *StatusError
+				require.ErrorAs(t, err, &statusErr)
+				assert.Equal(t, tt.statuses[len(tt.statuses)-1], statusErr.StatusCode)
+				assert.Contains(t, err.Error(), "получен неверный статус")
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, result)
+				assert.Equal(t, 1, result.UserCount)
+			}
+		})
+	}
+}
+
+func TestClient_SendUsers_RetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(t, &calls, http.Header{"Retry-After": {"1"}}, http.StatusServiceUnavailable)
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    2 * time.Second,
+	}}
+
+	started := time.Now()
+	_, err := client.SendUsers(context.Background(), retryUsers)
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(started), time.Second, "пауза из Retry-After")
+}
+
+func TestClient_SendUsers_RetryAfterTooLong(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(t, &calls, http.Header{"Retry-After": {"120"}}, http.StatusTooManyRequests)
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: fastRetry}
+
+	_, err := client.SendUsers(context.Background(), retryUsers)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "ждать дольше MaxDelay нельзя")
+}
+
+func TestClient_SendUsers_RetryDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(t, &calls, nil, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := client.SendUsers(ctx, retryUsers)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(started), 200*time.Millisecond, "пауза не укладывается в дедлайн - ждать не нужно")
+}
+
+func TestClient_SendUsers_RetryNetworkError(t *testing.T) {
+	client := &Client{URL: "http://localhost:99999", client: &http.Client{Timeout: time.Second}, retry: fastRetry}
+
+	_, err := client.SendUsers(context.Background(), retryUsers)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ошибка при отправке пользователей на сервер")
+}
+
+func TestClient_SendUsers_RetryAttemptTimeout(t *testing.T) {
+	// Первая попытка не укладывается в таймаут попытки, вторая успевает до дедлайна запроса
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`{"status": "success", "user_count": 1}`))
+	}))
+	defer server.Close()
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: 50 * time.Millisecond}, retry: fastRetry}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.SendUsers(ctx, retryUsers)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.UserCount)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_SendUsers_RetryCanceled(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(t, &calls, nil, http.StatusServiceUnavailable)
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Minute,
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := client.SendUsers(ctx, retryUsers)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(100))
+
+	policy.Jitter = 0.5
+	for range 100 {
+		delay := policy.backoff(2)
+		assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+		assert.LessOrEqual(t, delay, 200*time.Millisecond)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("скоро", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+}
@@ -12,34 +12,65 @@ import (
 	"github.com/NarthurN/GoXML_JSON/internal/models"
 )
 
-// SendUsers отправляет пользователей на сервер
-func (c *Client) SendUsers(ctx context.Context, users []models.JSONUser) ([]byte, error) {
+// SendUsers отправляет пользователей на сервер.
+// При сетевых ошибках и временных статусах (429, 503...) запрос повторяется по политике клиента.
+// Каждая попытка проходит через предохранитель: пока он разомкнут, возвращается *OpenError.
+// Все попытки отправляются с одним заголовком Idempotency-Key.
+// Ответ сервера разбирается и проверяется: пользователи, отклоненные сервером, - в Rejected.
+func (c *Client) SendUsers(ctx context.Context, users []models.JSONUser) (*models.UpstreamResponse, error) {
 	jsonData, err := json.Marshal(users)
 	if err != nil {
 		return nil, fmt.Errorf("❌ SendUsers: ошибка при конвертации пользователей в JSON: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewBuffer(jsonData))
+	key := idempotencyKey(ctx, jsonData)
+	bodyBytes, err := c.withRetry(ctx, "SendUsers", c.guard("SendUsers", func(ctx context.Context) ([]byte, error) {
+		return c.sendUsers(ctx, "SendUsers", jsonData, key)
+	}))
 	if err != nil {
-		return nil, fmt.Errorf("❌ SendUsers: ошибка при создании запроса: %w", err)
+		return nil, fmt.Errorf("❌ SendUsers: %w", err)
+	}
+
+	response, err := parseResponse(bodyBytes, len(users))
+	if err != nil {
+		return nil, fmt.Errorf("❌ SendUsers: %w", err)
+	}
+	return response, nil
+}
+
+// sendUsers - одна попытка отправки JSON массива пользователей с ключом идемпотентности key
+func (c *Client) sendUsers(ctx context.Context, op string, jsonData []byte, key string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании запроса: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("❌ SendUsers: ошибка при отправке пользователей на сервер: %w", err)
+		return nil, &sendError{err: err}
 	}
 
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("❌ SendUsers: ошибка чтения тела ответа: %w", err)
+		return nil, fmt.Errorf("ошибка чтения тела ответа: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("❌ SendUsers: получен неверный статус: %s - %s", resp.Status, string(bodyBytes))
+	// 207 Multi-Status и другие 2xx означают, что запрос принят: итог по пользователям - в теле ответа
+	if !isSuccess(resp.StatusCode) {
+		return nil, newStatusError(resp, bodyBytes)
+	}
+	if resp.Header.Get(IdempotentReplayedHeader) == "true" {
+		c.logf("♻️ %s: сервер уже принял запрос с ключом %s и повторил ответ", op, key)
 	}
 
 	return bodyBytes, nil
 }
+
+// isSuccess сообщает, что сервер принял запрос (статус 2xx)
+func isSuccess(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+}
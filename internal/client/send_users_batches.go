@@ -0,0 +1,276 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// BatchPolicy - разбиение пользователей на пакеты: сервер-получатель ограничивает размер запроса.
+// Нулевое значение означает один пакет со всеми пользователями.
+type BatchPolicy struct {
+	MaxUsers    int // Наибольшее число пользователей в пакете (0 - без ограничения)
+	MaxBytes    int // Наибольший размер JSON тела пакета, байт (0 - без ограничения)
+	Concurrency int // Сколько пакетов отправляется одновременно (0 и 1 - по очереди)
+}
+
+// WithBatchPolicy задает разбиение пользователей на пакеты
+func WithBatchPolicy(policy BatchPolicy) Option {
+	return func(c *Client) {
+		c.batch = policy
+	}
+}
+
+// BatchResult - результат отправки одного пакета
+type BatchResult struct {
+	Index    int                      `json:"index"`                    // Номер пакета, начиная с 0
+	First    int                      `json:"first"`                    // Номер первого пользователя пакета в исходном списке
+	Count    int                      `json:"count"`                    // Число пользователей в пакете
+	Bytes    int                      `json:"bytes"`                    // Размер JSON тела пакета
+	Key      string                   `json:"idempotencyKey,omitempty"` // Ключ идемпотентности пакета
+	Accepted int                      `json:"accepted"`                 // Пользователей, принятых сервером
+	Refused  int                      `json:"refused"`                  // Пользователей, отклоненных сервером
+	Response *models.UpstreamResponse `json:"-"`                        // Ответ сервера на пакет
+	Error    string                   `json:"error,omitempty"`          // Ошибка отправки пакета
+	Err      error                    `json:"-"`                        // Исходная ошибка
+}
+
+// Delivery - итог отправки пользователей пакетами
+type Delivery struct {
+	Batches   []BatchResult `json:"batches"`   // Пакеты в исходном порядке
+	Delivered int           `json:"delivered"` // Пользователей, принятых сервером
+	Refused   int           `json:"refused"`   // Пользователей, отклоненных сервером
+	Failed    int           `json:"failed"`    // Пользователей в пакетах с ошибкой
+}
+
+// Err объединяет ошибки пакетов через errors.Join; nil - все пакеты отправлены
+func (d *Delivery) Err() error {
+	var errs []error
+	for _, batch := range d.Batches {
+		if batch.Err != nil {
+			errs = append(errs, batch.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeliveryReport - итог отправки по каждому пользователю; Index - номер записи в XML документе
+type DeliveryReport struct {
+	Delivered []models.DeliveredUser `json:"delivered"` // Приняты сервером
+	Rejected  []models.RejectedUser  `json:"rejected"`  // Отклонены сервером, с причиной
+	Failed    []models.RejectedUser  `json:"failed"`    // Не отправлены: пакет с ошибкой
+}
+
+// Report сопоставляет результаты пакетов с отправленными пользователями users
+// (тем же списком, что передан в SendUsersBatches) и возвращает итог по каждому пользователю.
+// Номера из ответа сервера переводятся в номера записей документа (JSONUser.Index).
+func (d *Delivery) Report(users []models.JSONUser) DeliveryReport {
+	report := DeliveryReport{
+		Delivered: []models.DeliveredUser{},
+		Rejected:  []models.RejectedUser{},
+		Failed:    []models.RejectedUser{},
+	}
+	for _, batch := range d.Batches {
+		batchUsers := users[batch.First : batch.First+batch.Count]
+		if batch.Err != nil || batch.Response == nil {
+			for _, user := range batchUsers {
+				report.Failed = append(report.Failed, models.RejectedUser{Index: user.Index, ID: user.ID, Reason: batch.Error})
+			}
+			continue
+		}
+
+		reasons := make(map[int]string, len(batch.Response.Rejected))
+		for _, rejected := range batch.Response.Rejected {
+			reasons[rejected.Index] = rejected.Reason
+		}
+		for i, user := range batchUsers {
+			if reason, ok := reasons[i]; ok {
+				report.Rejected = append(report.Rejected, models.RejectedUser{Index: user.Index, ID: user.ID, Reason: reason})
+				continue
+			}
+			report.Delivered = append(report.Delivered, models.DeliveredUser{Index: user.Index, ID: user.ID})
+		}
+	}
+	return report
+}
+
+// batch - пакет, готовый к отправке
+type batch struct {
+	result BatchResult
+	body   []byte
+}
+
+// SendUsersBatches отправляет пользователей пакетами по политике клиента.
+// Пакет с ошибкой не прерывает отправку остальных: результат каждого пакета
+// собирается в Delivery. Каждый пакет повторяется и проходит через предохранитель, как SendUsers,
+// и отправляется со своим ключом идемпотентности.
+// Тело пакета кодируется, когда для него освободился отправитель, поэтому в памяти
+// одновременно не больше Concurrency тел пакетов и одного собираемого.
+func (c *Client) SendUsersBatches(ctx context.Context, users []models.JSONUser) (*Delivery, error) {
+	c.logf("📦 SendUsersBatches: %d пользователей, в пакете до %d пользователей и %d байт, одновременно: %d",
+		len(users), c.batch.MaxUsers, c.batch.MaxBytes, max(c.batch.Concurrency, 1))
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, max(c.batch.Concurrency, 1))
+		batches []*batch
+	)
+	err := c.eachBatch(users, func(b *batch) {
+		batches = append(batches, b)
+		if b.result.Err != nil {
+			return
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			c.sendBatch(ctx, "SendUsersBatches", b)
+		}()
+	})
+	wg.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("❌ SendUsersBatches: ошибка при конвертации пользователей в JSON: %w", err)
+	}
+
+	delivery := &Delivery{Batches: make([]BatchResult, len(batches))}
+	for i, b := range batches {
+		delivery.Batches[i] = b.result
+		if b.result.Err != nil {
+			delivery.Failed += b.result.Count
+		} else {
+			delivery.Delivered += b.result.Accepted
+			delivery.Refused += b.result.Refused
+		}
+	}
+	return delivery, nil
+}
+
+// sendBatch отправляет пакет с повторами и записывает результат в b; name - операция для лога.
+// Ключ идемпотентности вычисляется по телу пакета; после отправки тело освобождается.
+func (c *Client) sendBatch(ctx context.Context, name string, b *batch) {
+	defer func() { b.body = nil }()
+
+	op := fmt.Sprintf("%s: пакет %d", name, b.result.Index+1)
+	b.result.Key = idempotencyKey(ctx, b.body)
+	bodyBytes, err := c.withRetry(ctx, op, c.guard(op, func(ctx context.Context) ([]byte, error) {
+		return c.sendUsers(ctx, op, b.body, b.result.Key)
+	}))
+	if err != nil {
+		b.result.Err = fmt.Errorf("❌ %s: %w", op, err)
+		b.result.Error = err.Error()
+		return
+	}
+
+	response, err := parseResponse(bodyBytes, b.result.Count)
+	if err != nil {
+		b.result.Err = fmt.Errorf("❌ %s: %w", op, err)
+		b.result.Error = err.Error()
+		return
+	}
+	b.result.Response = response
+	b.result.Accepted = response.UserCount
+	b.result.Refused = len(response.Rejected)
+	if b.result.Refused > 0 {
+		c.logf("⚠️ %s: сервер отклонил %d из %d пользователей", op, b.result.Refused, b.result.Count)
+	}
+}
+
+// eachBatch разбивает пользователей на пакеты по числу и размеру JSON тела
+// и передает пакеты в send по порядку. Пользователи кодируются по одному,
+// следующий пакет собирается только после возврата из send.
+// Пользователь, который один не помещается в MaxBytes, попадает в отдельный пакет с ошибкой.
+func (c *Client) eachBatch(users []models.JSONUser, send func(*batch)) error {
+	builder := batchBuilder{policy: c.batch}
+	index, first := 0, 0
+	flush := func() {
+		if builder.count == 0 {
+			return
+		}
+		body, count := builder.take()
+		send(&batch{
+			result: BatchResult{Index: index, First: first, Count: count, Bytes: len(body)},
+			body:   body,
+		})
+		index++
+	}
+
+	for i, user := range users {
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+
+		if builder.tooLarge(data) {
+			flush()
+			err := fmt.Errorf("%w: пользователь %d занимает %d байт", models.ErrBatchTooLarge, i, len(data)+2)
+			send(&batch{result: BatchResult{
+				Index: index,
+				First: i,
+				Count: 1,
+				Bytes: len(data) + 2,
+				Error: err.Error(),
+				Err:   err,
+			}})
+			index++
+			continue
+		}
+
+		if !builder.fits(data) {
+			flush()
+		}
+		if builder.count == 0 {
+			first = i
+		}
+		builder.add(data)
+	}
+	flush()
+	return nil
+}
+
+// batchBuilder собирает JSON тело пакета из закодированных пользователей
+type batchBuilder struct {
+	policy BatchPolicy
+	body   []byte // Открывающая скобка массива и пользователи через запятую
+	count  int
+}
+
+// tooLarge сообщает, что пользователь не помещается даже в пустой пакет
+func (b *batchBuilder) tooLarge(data []byte) bool {
+	return b.policy.MaxBytes > 0 && len(data)+2 > b.policy.MaxBytes
+}
+
+// fits сообщает, что пользователь помещается в текущий пакет.
+// Тело пакета: скобки массива, пользователи и запятые между ними.
+func (b *batchBuilder) fits(data []byte) bool {
+	if b.count == 0 {
+		return true
+	}
+	full := b.policy.MaxUsers > 0 && b.count == b.policy.MaxUsers
+	tooBig := b.policy.MaxBytes > 0 && len(b.body)+len(data)+2 > b.policy.MaxBytes
+	return !full && !tooBig
+}
+
+// add дописывает пользователя в пакет
+func (b *batchBuilder) add(data []byte) {
+	if b.count == 0 {
+		b.body = append(b.body, '[')
+	} else {
+		b.body = append(b.body, ',')
+	}
+	b.body = append(b.body, data...)
+	b.count++
+}
+
+// take возвращает тело пакета и число пользователей в нем и начинает новый пакет
+func (b *batchBuilder) take() ([]byte, int) {
+	body, count := append(b.body, ']'), b.count
+	b.body, b.count = nil, 0
+	return body, count
+}
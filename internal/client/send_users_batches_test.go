@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchUsers возвращает count пользователей с ID от 1 до count
+func batchUsers(count int) []models.JSONUser {
+	users := make([]models.JSONUser, count)
+	for i := range users {
+		users[i] = models.JSONUser{
+			ID:       fmt.Sprintf("%d", i+1),
+			FullName: fmt.Sprintf("Пользователь %d", i+1),
+			Email:    fmt.Sprintf("user%d@example.com", i+1),
+			AgeGroup: "от 25 до 35",
+		}
+	}
+	return users
+}
+
+// splitUsers возвращает пакеты, на которые клиент разбивает users, не отправляя их
+func splitUsers(t *testing.T, client *Client, users []models.JSONUser) []*batch {
+	t.Helper()
+	var batches []*batch
+	require.NoError(t, client.eachBatch(users, func(b *batch) {
+		batches = append(batches, b)
+	}))
+	return batches
+}
+
+func TestClient_SplitUsers(t *testing.T) {
+	users := batchUsers(10)
+	size := len(mustMarshal(t, users[0]))
+
+	tests := []struct {
+		name   string
+		policy BatchPolicy
+		counts []int
+	}{
+		{name: "без ограничений", policy: BatchPolicy{}, counts: []int{10}},
+		{name: "по числу", policy: BatchPolicy{MaxUsers: 4}, counts: []int{4, 4, 2}},
+		{name: "по размеру", policy: BatchPolicy{MaxBytes: 3*size + 4}, counts: []int{3, 3, 3, 1}},
+		{name: "размер на байт меньше", policy: BatchPolicy{MaxBytes: 3*size + 3}, counts: []int{2, 2, 2, 2, 2}},
+		{name: "по числу и размеру", policy: BatchPolicy{MaxUsers: 2, MaxBytes: 3*size + 4}, counts: []int{2, 2, 2, 2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{batch: tt.policy}
+
+			batches := splitUsers(t, client, users)
+
+			var counts []int
+			first := 0
+			for i, b := range batches {
+				counts = append(counts, b.result.Count)
+				assert.Equal(t, i, b.result.Index)
+				assert.Equal(t, first, b.result.First)
+				assert.Equal(t, len(b.body), b.result.Bytes)
+				if tt.policy.MaxBytes > 0 {
+					assert.LessOrEqual(t, len(b.body), tt.policy.MaxBytes)
+				}
+
+				var decoded []models.JSONUser
+				require.NoError(t, json.Unmarshal(b.body, &decoded))
+				assert.Equal(t, users[first:first+b.result.Count], decoded)
+				first += b.result.Count
+			}
+			assert.Equal(t, tt.counts, counts)
+		})
+	}
+}
+
+// countingValue считает, сколько раз его закодировали в JSON
+type countingValue struct {
+	calls *atomic.Int32
+}
+
+func (v countingValue) MarshalJSON() ([]byte, error) {
+	v.calls.Add(1)
+	return []byte(`"x"`), nil
+}
+
+func TestClient_SplitUsers_Lazy(t *testing.T) {
+	var marshaled atomic.Int32
+	users := batchUsers(10)
+	for i := range users {
+		users[i].Extra = map[string]any{"counter": countingValue{calls: &marshaled}}
+	}
+	client := &Client{batch: BatchPolicy{MaxUsers: 2}}
+
+	var atSend []int32
+	require.NoError(t, client.eachBatch(users, func(b *batch) {
+		atSend = append(atSend, marshaled.Load())
+	}))
+
+	// К отправке пакета закодированы только его пользователи и первый пользователь следующего
+	assert.Equal(t, []int32{3, 5, 7, 9, 10}, atSend)
+}
+
+func TestClient_SplitUsers_UserTooLarge(t *testing.T) {
+	users := batchUsers(3)
+	users[1].FullName = strings.Repeat("Я", 100)
+	client := &Client{batch: BatchPolicy{MaxBytes: 150}}
+
+	batches := splitUsers(t, client, users)
+
+	require.Len(t, batches, 3)
+	assert.NoError(t, batches[0].result.Err)
+	assert.ErrorIs(t, batches[1].result.Err, models.ErrBatchTooLarge)
+	assert.Equal(t, 1, batches[1].result.First)
+	assert.Nil(t, batches[1].body)
+	assert.Equal(t, 2, batches[2].result.First)
+}
+
+func TestClient_SendUsersBatches(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+		if users[0].ID == "5" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "bad batch"}`))
+			return
+		}
+		mu.Lock()
+		for _, user := range users {
+			received = append(received, user.ID)
+		}
+		mu.Unlock()
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(users))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 2},
+	}
+
+	delivery, err := client.SendUsersBatches(context.Background(), batchUsers(5))
+
+	require.NoError(t, err)
+	require.Len(t, delivery.Batches, 3)
+	assert.Equal(t, 4, delivery.Delivered)
+	assert.Equal(t, 1, delivery.Failed)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, received, "по очереди - в исходном порядке")
+
+	require.NotNil(t, delivery.Batches[0].Response)
+	assert.Equal(t, 2, delivery.Batches[0].Response.UserCount)
+	assert.Equal(t, 2, delivery.Batches[0].Accepted)
+	assert.Equal(t, 4, delivery.Batches[2].First)
+	var statusErr *StatusError
+	require.ErrorAs(t, delivery.Batches[2].Err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	assert.Contains(t, delivery.Batches[2].Error, "bad batch")
+	assert.ErrorAs(t, delivery.Err(), &statusErr)
+}
+
+func TestClient_SendUsersBatches_Concurrency(t *testing.T) {
+	var active, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"status": "success", "user_count": 1}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 1, Concurrency: 3},
+	}
+
+	delivery, err := client.SendUsersBatches(context.Background(), batchUsers(9))
+
+	require.NoError(t, err)
+	assert.NoError(t, delivery.Err())
+	assert.Equal(t, 9, delivery.Delivered)
+	assert.Equal(t, int32(3), peak.Load())
+	for i, batch := range delivery.Batches {
+		assert.Equal(t, i, batch.First, "результаты в исходном порядке")
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
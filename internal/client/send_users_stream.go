@@ -0,0 +1,342 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// UsersProducer - источник пользователей для потоковой отправки.
+// Вызывает emit для каждого пользователя; ошибка emit означает, что отправка прервана.
+type UsersProducer func(emit func(models.JSONUser) error) error
+
+// StreamDelivery - итог потоковой отправки. Принятые пользователи только подсчитываются,
+// а отклоненные сервером перечисляются с номерами записей документа (JSONUser.Index),
+// поэтому память не растет с числом отправленных пользователей.
+type StreamDelivery struct {
+	*Delivery
+	Rejected []models.RejectedUser `json:"-"` // Отклонены сервером, с причиной
+}
+
+// SendUsersStream отправляет пользователей на сервер по мере их получения от produce.
+// Если политика клиента ограничивает пакеты, пользователи отправляются пакетами (см. sendUsersChunks);
+// тогда при ошибке возвращается и итог по уже отправленным пакетам.
+// Иначе JSON массив пишется в тело одного запроса через io.Pipe (chunked transfer), поэтому
+// память не зависит от числа пользователей, а медленный сервер притормаживает produce.
+// Запрос не отправляется, если produce не выдал ни одного пользователя.
+// Тело запроса нельзя прочитать повторно, поэтому такая отправка не повторяется,
+// но проходит через предохранитель, как и SendUsers. Ключ идемпотентности вычисляется по ID запроса
+// и телу и передается в трейлере запроса (см. streamKey). Ответ сервера проверяется по числу
+// отправленных пользователей.
+func (c *Client) SendUsersStream(ctx context.Context, produce UsersProducer) (*StreamDelivery, error) {
+	if c.batch.MaxUsers > 0 || c.batch.MaxBytes > 0 {
+		return c.sendUsersChunks(ctx, produce)
+	}
+
+	var records recordIndex
+	key := newStreamKey(ctx)
+	bodyBytes, err := c.guard("SendUsersStream", func(ctx context.Context) ([]byte, error) {
+		return c.sendUsersStream(ctx, produce, key, &records)
+	})(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := parseResponse(bodyBytes, records.count)
+	if err != nil {
+		return nil, fmt.Errorf("❌ SendUsersStream: %w", err)
+	}
+
+	delivery := &StreamDelivery{
+		Delivery: &Delivery{
+			Batches: []BatchResult{{
+				Count:    records.count,
+				Key:      key.key(),
+				Accepted: response.UserCount,
+				Refused:  len(response.Rejected),
+				Response: response,
+			}},
+			Delivered: response.UserCount,
+			Refused:   len(response.Rejected),
+		},
+		Rejected: records.rejected(0, response.Rejected),
+	}
+	return delivery, nil
+}
+
+// sendUsersChunks отправляет пользователей от produce пакетами по политике клиента.
+// Пакет уходит, как только наберется, и produce ждет его отправки, поэтому в памяти
+// находится один пакет. Пакеты отправляются по очереди, с повторами, через предохранитель
+// и со своим ключом идемпотентности, как в SendUsersBatches. Пользователь, который один
+// больше MaxBytes, попадает в отдельный пакет с ошибкой, остальные отправляются.
+// Пакет, который не удалось отправить, прекращает чтение: возвращается его ошибка
+// вместе с итогом по уже отправленным пакетам.
+func (c *Client) sendUsersChunks(ctx context.Context, produce UsersProducer) (*StreamDelivery, error) {
+	delivery := &StreamDelivery{Delivery: &Delivery{}, Rejected: []models.RejectedUser{}}
+	var (
+		records  recordIndex
+		builder  = batchBuilder{policy: c.batch}
+		first    int
+		chunkErr error
+	)
+	flush := func() error {
+		if builder.count == 0 {
+			return nil
+		}
+		body, count := builder.take()
+		b := &batch{
+			result: BatchResult{Index: len(delivery.Batches), First: first, Count: count, Bytes: len(body)},
+			body:   body,
+		}
+		c.sendBatch(ctx, "SendUsersStream", b)
+
+		if b.result.Err != nil {
+			delivery.Batches = append(delivery.Batches, b.result)
+			delivery.Failed += b.result.Count
+			chunkErr = b.result.Err
+			return chunkErr
+		}
+		// Пользователи и результаты по каждому из ответа не хранятся: память не должна расти с документом
+		response := b.result.Response
+		delivery.Rejected = append(delivery.Rejected, records.rejected(first, response.Rejected)...)
+		response.Users, response.Results = nil, nil
+		delivery.Batches = append(delivery.Batches, b.result)
+		delivery.Delivered += b.result.Accepted
+		delivery.Refused += b.result.Refused
+		return nil
+	}
+
+	err := produce(func(user models.JSONUser) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+
+		position := records.count
+		records.add(user.Index)
+		if builder.tooLarge(data) {
+			if err := flush(); err != nil {
+				return err
+			}
+			err := fmt.Errorf("%w: пользователь %d занимает %d байт", models.ErrBatchTooLarge, position, len(data)+2)
+			delivery.Batches = append(delivery.Batches, BatchResult{
+				Index: len(delivery.Batches),
+				First: position,
+				Count: 1,
+				Bytes: len(data) + 2,
+				Error: err.Error(),
+				Err:   err,
+			})
+			delivery.Failed++
+			return nil
+		}
+
+		if !builder.fits(data) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if builder.count == 0 {
+			first = position
+		}
+		builder.add(data)
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	switch {
+	case chunkErr != nil:
+		return delivery, chunkErr
+	case err != nil:
+		return delivery, fmt.Errorf("❌ SendUsersStream: ошибка при формировании тела запроса: %w", err)
+	case records.count == 0:
+		return nil, models.ErrNoUsers
+	}
+	c.logf("📦 SendUsersStream: %d пользователей в %d пакетах", records.count, len(delivery.Batches))
+	return delivery, nil
+}
+
+// sendUsersStream - отправка пользователей от produce с ключом идемпотентности key (nil - без ключа);
+// в records записываются номера записей отправленных
+func (c *Client) sendUsersStream(ctx context.Context, produce UsersProducer, key *streamKey, records *recordIndex) ([]byte, error) {
+	pr, pw := io.Pipe()
+	first := make(chan struct{})
+	produced := make(chan error, 1)
+
+	go func() {
+		produced <- writeUsersArray(ctx, pw, produce, first, records, key)
+	}()
+
+	select {
+	case <-first:
+	case err := <-produced:
+		// Ни одного пользователя не было записано в тело
+		pr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("❌ SendUsersStream: ошибка при формировании тела запроса: %w", err)
+		}
+		return nil, models.ErrNoUsers
+	case <-ctx.Done():
+		pr.CloseWithError(ctx.Err())
+		<-produced
+		return nil, fmt.Errorf("❌ SendUsersStream: %w", ctx.Err())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, pr)
+	if err != nil {
+		pr.Close()
+		<-produced
+		return nil, fmt.Errorf("❌ SendUsersStream: ошибка при создании запроса: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != nil {
+		req.Trailer = key.trailer
+	}
+
+	// Таймаут попытки рассчитан на пакет, а конвейер пишет тело все время чтения документа:
+	// его ограничивает только дедлайн ctx
+	stream := *c.client
+	stream.Timeout = 0
+	resp, err := stream.Do(req)
+	if err != nil {
+		pr.Close()
+		if produceErr := <-produced; isProduceFailure(produceErr) {
+			return nil, fmt.Errorf("❌ SendUsersStream: ошибка при формировании тела запроса: %w", produceErr)
+		}
+		return nil, fmt.Errorf("❌ SendUsersStream: %w", &sendError{err: err})
+	}
+
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+
+	// Сервер уже ответил: освобождаем produce, если он еще пишет в тело
+	pr.Close()
+	if produceErr := <-produced; isProduceFailure(produceErr) {
+		return nil, fmt.Errorf("❌ SendUsersStream: ошибка при формировании тела запроса: %w", produceErr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("❌ SendUsersStream: ошибка чтения тела ответа: %w", err)
+	}
+
+	if !isSuccess(resp.StatusCode) {
+		return nil, fmt.Errorf("❌ SendUsersStream: %w", newStatusError(resp, bodyBytes))
+	}
+
+	return bodyBytes, nil
+}
+
+// isProduceFailure сообщает, что produce завершился по собственной ошибке,
+// а не потому, что тело запроса было закрыто транспортом
+func isProduceFailure(err error) bool {
+	return err != nil && !errors.Is(err, io.ErrClosedPipe)
+}
+
+// writeUsersArray пишет пользователей от produce в pw в виде JSON массива.
+// first закрывается перед записью первого пользователя.
+// После отмены ctx emit возвращает ошибку, чтобы produce не читал данные впустую.
+// Номера записей записанных пользователей добавляются в records.
+// Если задан key, тело учитывается в ключе, а ключ записывается в трейлер до закрытия pw.
+func writeUsersArray(ctx context.Context, pw *io.PipeWriter, produce UsersProducer, first chan<- struct{}, records *recordIndex, key *streamKey) error {
+	var out io.Writer = pw
+	if key != nil {
+		out = io.MultiWriter(pw, key.hash)
+	}
+	buf := bufio.NewWriter(out)
+	count := 0
+
+	err := produce(func(user models.JSONUser) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			close(first)
+			err = buf.WriteByte('[')
+		} else {
+			err = buf.WriteByte(',')
+		}
+		if err != nil {
+			return err
+		}
+		count++
+		records.add(user.Index)
+
+		_, err = buf.Write(data)
+		return err
+	})
+
+	if err == nil && count > 0 {
+		if err = buf.WriteByte(']'); err == nil {
+			err = buf.Flush()
+		}
+	}
+
+	if err != nil && count > 0 {
+		// Обрываем тело запроса, чтобы сервер не получил неполный массив
+		pw.CloseWithError(err)
+		return err
+	}
+	if err == nil && key != nil {
+		key.finish()
+	}
+	pw.Close()
+	return err
+}
+
+// recordIndex переводит номер пользователя в потоке в номер записи документа.
+// Хранит только начала серий подряд идущих записей, поэтому растет с числом пропущенных
+// записей (невалидных и повторов), а не с числом отправленных пользователей.
+type recordIndex struct {
+	runs  []indexRun
+	count int // Сколько пользователей добавлено
+}
+
+// indexRun - серия пользователей, идущих подряд с записи record
+type indexRun struct {
+	position int // Номер первого пользователя серии в потоке
+	record   int // Номер его записи в документе
+}
+
+// add добавляет следующего пользователя потока с номером записи record
+func (x *recordIndex) add(record int) {
+	if n := len(x.runs); n == 0 || x.runs[n-1].record+x.count-x.runs[n-1].position != record {
+		x.runs = append(x.runs, indexRun{position: x.count, record: record})
+	}
+	x.count++
+}
+
+// record возвращает номер записи документа для пользователя position
+func (x *recordIndex) record(position int) int {
+	i := sort.Search(len(x.runs), func(i int) bool { return x.runs[i].position > position }) - 1
+	return x.runs[i].record + position - x.runs[i].position
+}
+
+// rejected переводит номера отклоненных пользователей пакета, начинающегося с пользователя first,
+// в номера записей документа
+func (x *recordIndex) rejected(first int, rejected []models.RejectedUser) []models.RejectedUser {
+	result := make([]models.RejectedUser, len(rejected))
+	for i, user := range rejected {
+		user.Index = x.record(first + user.Index)
+		result[i] = user
+	}
+	return result
+}
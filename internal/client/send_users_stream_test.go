@@ -0,0 +1,370 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// produceUsers возвращает источник, выдающий count пользователей
+func produceUsers(count int) UsersProducer {
+	return func(emit func(models.JSONUser) error) error {
+		for i := 1; i <= count; i++ {
+			err := emit(models.JSONUser{
+				ID:       fmt.Sprintf("%d", i),
+				FullName: fmt.Sprintf("Пользователь %d", i),
+				Email:    fmt.Sprintf("user%d@example.com", i),
+				AgeGroup: "от 25 до 35",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestClient_SendUsersStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		// Тело передается потоково, без заранее известной длины
+		assert.Equal(t, []string{"chunked"}, r.TransferEncoding)
+
+		var receivedUsers []models.JSONUser
+		err := json.NewDecoder(r.Body).Decode(&receivedUsers)
+		require.NoError(t, err)
+		assert.Len(t, receivedUsers, 10000)
+		assert.Equal(t, "1", receivedUsers[0].ID)
+		assert.Equal(t, "10000", receivedUsers[9999].ID)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "success", "user_count": 10000}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	result, err := client.SendUsersStream(context.Background(), produceUsers(10000))
+
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 10000, result.Delivered)
+	assert.Equal(t, 10000, result.Batches[0].Response.UserCount)
+	assert.Empty(t, result.Rejected)
+}
+
+func TestClient_SendUsersStream_Rejected(t *testing.T) {
+	// Сервер отклоняет каждого третьего пользователя
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+
+		response := models.UpstreamResponse{Status: models.UpstreamStatusPartial}
+		for i, user := range users {
+			if i%3 == 2 {
+				response.Rejected = append(response.Rejected, models.RejectedUser{Index: i, ID: user.ID, Reason: "ID уже занят"})
+			} else {
+				response.UserCount++
+			}
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	// Записи 2 и 3 документа пропущены: номера пользователей и записей расходятся
+	records := []int{0, 1, 4, 5, 6, 7}
+	produce := func(emit func(models.JSONUser) error) error {
+		for i, record := range records {
+			if err := emit(models.JSONUser{ID: fmt.Sprint(i + 1), Index: record}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}}
+
+	result, err := client.SendUsersStream(context.Background(), produce)
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Delivered)
+	assert.Equal(t, 2, result.Refused)
+	assert.Equal(t, []models.RejectedUser{
+		{Index: 4, ID: "3", Reason: "ID уже занят"},
+		{Index: 7, ID: "6", Reason: "ID уже занят"},
+	}, result.Rejected)
+}
+
+func TestClient_SendUsersStream_Chunks(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		sizes []int
+		keys  = map[string]bool{}
+	)
+	// Сервер отклоняет пользователей с ID, кратным 5
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+		mu.Lock()
+		sizes = append(sizes, len(users))
+		keys[r.Header.Get(IdempotencyKeyHeader)] = true
+		mu.Unlock()
+
+		response := models.UpstreamResponse{Status: models.UpstreamStatusPartial, Users: users}
+		for i, user := range users {
+			if id, _ := strconv.Atoi(user.ID); id%5 == 0 {
+				response.Rejected = append(response.Rejected, models.RejectedUser{Index: i, ID: user.ID, Reason: "ID уже занят"})
+			} else {
+				response.UserCount++
+			}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+	defer server.Close()
+
+	// Номер записи - вдвое больше номера пользователя: каждая вторая запись пропущена
+	produce := func(emit func(models.JSONUser) error) error {
+		return produceUsers(10)(func(user models.JSONUser) error {
+			id, _ := strconv.Atoi(user.ID)
+			user.Index = 2 * (id - 1)
+			return emit(user)
+		})
+	}
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 4},
+	}
+
+	result, err := client.SendUsersStream(withReqID("req-1"), produce)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 4, 2}, sizes)
+	assert.Len(t, keys, 3, "у каждого пакета свой ключ идемпотентности")
+	require.Len(t, result.Batches, 3)
+	assert.Equal(t, 8, result.Delivered)
+	assert.Equal(t, 2, result.Refused)
+	assert.Equal(t, 4, result.Batches[1].First)
+	assert.Nil(t, result.Batches[0].Response.Users, "эхо пользователей не хранится")
+	assert.Equal(t, []models.RejectedUser{
+		{Index: 8, ID: "5", Reason: "ID уже занят"},
+		{Index: 18, ID: "10", Reason: "ID уже занят"},
+	}, result.Rejected)
+}
+
+func TestClient_SendUsersStream_ChunkFailed(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+		if calls.Add(1) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(users))
+	}))
+	defer server.Close()
+
+	var emitted int
+	produce := func(emit func(models.JSONUser) error) error {
+		return produceUsers(100)(func(user models.JSONUser) error {
+			emitted++
+			return emit(user)
+		})
+	}
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxUsers: 3},
+	}
+
+	result, err := client.SendUsersStream(context.Background(), produce)
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	assert.Equal(t, int32(2), calls.Load(), "после неудачного пакета отправка прекращается")
+	assert.Equal(t, 7, emitted, "produce останавливается на пользователе, который не поместился в неудачный пакет")
+
+	// Итог по уже отправленным пакетам возвращается вместе с ошибкой
+	require.NotNil(t, result)
+	require.Len(t, result.Batches, 2)
+	assert.Equal(t, 3, result.Delivered)
+	assert.Equal(t, 3, result.Failed)
+	assert.Error(t, result.Batches[1].Err)
+}
+
+func TestClient_SendUsersStream_ChunkUserTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(users))
+	}))
+	defer server.Close()
+
+	produce := func(emit func(models.JSONUser) error) error {
+		for _, user := range batchUsers(3) {
+			if user.ID == "2" {
+				user.FullName = strings.Repeat("Я", 100)
+			}
+			if err := emit(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+		batch:  BatchPolicy{MaxBytes: 150},
+	}
+
+	result, err := client.SendUsersStream(context.Background(), produce)
+
+	require.NoError(t, err)
+	require.Len(t, result.Batches, 3)
+	assert.Equal(t, 2, result.Delivered)
+	assert.Equal(t, 1, result.Failed)
+	assert.ErrorIs(t, result.Batches[1].Err, models.ErrBatchTooLarge)
+	assert.Equal(t, 1, result.Batches[1].First)
+	assert.Equal(t, 2, result.Batches[2].First)
+}
+
+func TestRecordIndex(t *testing.T) {
+	var index recordIndex
+	records := []int{0, 1, 2, 5, 6, 9, 10, 11, 12, 20}
+	for _, record := range records {
+		index.add(record)
+	}
+
+	assert.Equal(t, len(records), index.count)
+	assert.Len(t, index.runs, 4, "хранятся только начала серий")
+	for position, record := range records {
+		assert.Equal(t, record, index.record(position))
+	}
+
+	var sequential recordIndex
+	for record := range 100000 {
+		sequential.add(record)
+	}
+	assert.Len(t, sequential.runs, 1, "без пропусков память не растет")
+	assert.Equal(t, 99999, sequential.record(99999))
+}
+
+func TestClient_SendUsersStream_NoUsers(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+	}
+
+	result, err := client.SendUsersStream(context.Background(), produceUsers(0))
+
+	assert.ErrorIs(t, err, models.ErrNoUsers)
+	assert.Nil(t, result)
+	assert.Equal(t, int32(0), calls.Load(), "запрос не должен отправляться без пользователей")
+}
+
+func TestClient_SendUsersStream_ProducerError(t *testing.T) {
+	errBroken := errors.New("сломанный XML")
+
+	tests := []struct {
+		name    string
+		produce UsersProducer
+	}{
+		{
+			name: "ошибка до первого пользователя",
+			produce: func(emit func(models.JSONUser) error) error {
+				return errBroken
+			},
+		},
+		{
+			name: "ошибка посреди потока",
+			produce: func(emit func(models.JSONUser) error) error {
+				if err := produceUsers(100)(emit); err != nil {
+					return err
+				}
+				return errBroken
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var receivedUsers []models.JSONUser
+				if err := json.NewDecoder(r.Body).Decode(&receivedUsers); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := &Client{
+				URL:    server.URL,
+				client: &http.Client{Timeout: time.Second},
+			}
+
+			result, err := client.SendUsersStream(context.Background(), tt.produce)
+
+			assert.ErrorIs(t, err, errBroken)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestClient_SendUsersStream_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "Internal server error"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		URL:    server.URL,
+		client: &http.Client{Timeout: time.Second},
+	}
+
+	result, err := client.SendUsersStream(context.Background(), produceUsers(3))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "получен неверный статус")
+}
+
+func TestClient_SendUsersStream_NetworkError(t *testing.T) {
+	client := &Client{
+		URL:    "http://localhost:99999", // Несуществующий порт
+		client: &http.Client{Timeout: time.Second},
+	}
+
+	result, err := client.SendUsersStream(context.Background(), produceUsers(3))
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "ошибка при отправке пользователей на сервер")
+}
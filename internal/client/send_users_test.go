@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,7 +20,7 @@ func TestClient_SendUsers(t *testing.T) {
 		users          []models.JSONUser
 		serverResponse func(w http.ResponseWriter, r *http.Request)
 		expectedError  bool
-		expectedBody   string
+		expectedCount  int
 	}{
 		{
 			name: "успешная отправка пользователей",
@@ -52,10 +53,10 @@ func TestClient_SendUsers(t *testing.T) {
 
 				// Отправляем успешный ответ
 				w.WriteHeader(http.StatusOK)
-				w.Write([]byte(`{"status": "success", "message": "Users processed"}`))
+				w.Write([]byte(`{"status": "success", "message": "Users processed", "user_count": 2}`))
 			},
 			expectedError: false,
-			expectedBody:  `{"status": "success", "message": "Users processed"}`,
+			expectedCount: 2,
 		},
 		{
 			name:  "пустой массив пользователей",
@@ -73,7 +74,7 @@ func TestClient_SendUsers(t *testing.T) {
 				w.Write([]byte(`{"status": "success", "message": "No users to process"}`))
 			},
 			expectedError: false,
-			expectedBody:  `{"status": "success", "message": "No users to process"}`,
+			expectedCount: 0,
 		},
 		{
 			name: "сервер возвращает ошибку 400",
@@ -149,8 +150,9 @@ func TestClient_SendUsers(t *testing.T) {
 				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, result)
-				assert.Equal(t, tt.expectedBody, string(result))
+				require.NotNil(t, result)
+				assert.Equal(t, models.UpstreamStatusSuccess, result.Status)
+				assert.Equal(t, tt.expectedCount, result.UserCount)
 			}
 		})
 	}
@@ -181,34 +183,66 @@ func TestClient_SendUsers_NetworkError(t *testing.T) {
 }
 
 func TestClient_SendUsers_EmptyResponse(t *testing.T) {
-	// Создаем тестовый сервер, который не отправляет тело ответа
+	// Сервер подтверждает прием статусом 2xx без тела ответа: все пользователи приняты
+	statuses := []struct {
+		name   string
+		status int
+	}{
+		{name: "200 без тела", status: http.StatusOK},
+		{name: "202 без тела", status: http.StatusAccepted},
+		{name: "204", status: http.StatusNoContent},
+	}
+
+	for _, tt := range statuses {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := &Client{
+				URL: server.URL,
+				client: &http.Client{
+					Timeout: 1 * time.Second,
+				},
+			}
+
+			result, err := client.SendUsers(context.Background(), []models.JSONUser{
+				{ID: "1", FullName: "Иван Иванов", Email: "ivan@example.com", AgeGroup: "от 25 до 35"},
+				{ID: "2", FullName: "Петр Петров", Email: "petr@example.com", AgeGroup: "от 25 до 35"},
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, models.UpstreamStatusSuccess, result.Status)
+			assert.Equal(t, 2, result.UserCount)
+			assert.Empty(t, result.Rejected)
+
+			stream, err := client.SendUsersStream(context.Background(), produceUsers(3))
+
+			require.NoError(t, err)
+			assert.Equal(t, 3, stream.Delivered)
+			assert.Empty(t, stream.Rejected)
+		})
+	}
+}
+
+func TestClient_SendUsers_NoUserCount(t *testing.T) {
+	// Сервер принял данные, но не сообщил число принятых: повтор записал бы их второй раз
+	var calls atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		// Не отправляем тело ответа
+		calls.Add(1)
+		w.Write([]byte(`{"status": "success", "message": "принято"}`))
 	}))
 	defer server.Close()
 
-	client := &Client{
-		URL: server.URL,
-		client: &http.Client{
-			Timeout: 1 * time.Second,
-		},
-	}
+	client := &Client{URL: server.URL, client: &http.Client{Timeout: time.Second}, retry: fastRetry}
 
-	ctx := context.Background()
-	result, err := client.SendUsers(ctx, []models.JSONUser{
-		{
-			ID:       "1",
-			FullName: "Иван Иванов",
-			Email:    "ivan@example.com",
-			AgeGroup: "от 25 до 35",
-		},
-	})
+	result, err := client.SendUsers(context.Background(), batchUsers(2))
 
-	// Должен быть успешным, даже с пустым телом ответа
-	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, "", string(result))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "запрос не повторяется")
+	assert.Equal(t, 2, result.UserCount)
+	assert.Empty(t, result.Rejected)
 }
 
 func TestClient_SendUsers_ContextCancellation(t *testing.T) {
@@ -254,7 +288,7 @@ func BenchmarkClient_SendUsers(b *testing.B) {
 	// Создаем тестовый сервер
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "success"}`))
+		w.Write([]byte(`{"status": "success", "user_count": 2}`))
 	}))
 	defer server.Close()
 
@@ -1,19 +1,262 @@
 package converter
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// DefaultAgeScheme - название схемы возрастных групп по умолчанию
+const DefaultAgeScheme = "default"
+
 const (
 	AgeGroupYang   = "до 25"
 	AgeGroupMiddle = "от 25 до 35"
 	AgeGroupOld    = "старше 35"
 )
 
-// GetAgeGroup - функция для определения возрастной группы
+// Машинные коды возрастных групп по умолчанию
+const (
+	AgeGroupCodeYang   = "lt25"
+	AgeGroupCodeMiddle = "25_35"
+	AgeGroupCodeOld    = "gt35"
+)
+
+// AgeRange - одно правило таблицы возрастных групп.
+// Отсутствующая граница означает, что диапазон не ограничен с этой стороны.
+type AgeRange struct {
+	Min          *int   `json:"min,omitempty"`           // Нижняя граница
+	MinExclusive bool   `json:"min_exclusive,omitempty"` // Нижняя граница не входит в диапазон
+	Max          *int   `json:"max,omitempty"`           // Верхняя граница
+	MaxExclusive bool   `json:"max_exclusive,omitempty"` // Верхняя граница не входит в диапазон
+	Code         string `json:"code"`                    // Стабильный машинный код группы
+	Label        string `json:"label"`                   // Название группы на русском языке
+}
+
+// bounds возвращает включительные целые границы диапазона
+func (r AgeRange) bounds() (int, int) {
+	low, high := math.MinInt, math.MaxInt
+	if r.Min != nil {
+		low = *r.Min
+		if r.MinExclusive {
+			low++
+		}
+	}
+	if r.Max != nil {
+		high = *r.Max
+		if r.MaxExclusive {
+			high--
+		}
+	}
+	return low, high
+}
+
+// AgeGroupRules - упорядоченная таблица возрастных групп.
+// Диапазоны идут по возрастанию и покрывают все возрасты без пересечений и пропусков.
+type AgeGroupRules struct {
+	Name   string     `json:"-"` // Название схемы
+	Ranges []AgeRange `json:"ranges"`
+
+	lows []int // Включительные нижние границы для бинарного поиска
+}
+
+// NewAgeGroupRules создает таблицу возрастных групп и проверяет ее корректность
+func NewAgeGroupRules(ranges []AgeRange) (*AgeGroupRules, error) {
+	rules := &AgeGroupRules{Ranges: ranges}
+	if err := rules.compile(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LoadAgeGroupRules загружает таблицу возрастных групп из JSON файла
+func LoadAgeGroupRules(path string) (*AgeGroupRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules AgeGroupRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", models.ErrInvalidAgeGroups, path, err)
+	}
+	if err := rules.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &rules, nil
+}
+
+// AgeGroupSchemes - набор именованных таблиц возрастных групп
+type AgeGroupSchemes struct {
+	Default string                    `json:"default"` // Схема, используемая если в запросе схема не указана
+	Schemes map[string]*AgeGroupRules `json:"schemes"`
+	Labels  LabelCatalog              `json:"labels"` // Переводы названий групп по кодам
+}
+
+// LoadAgeGroupSchemes загружает именованные схемы возрастных групп из JSON файла
+func LoadAgeGroupSchemes(path string) (*AgeGroupSchemes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schemes AgeGroupSchemes
+	if err := json.Unmarshal(data, &schemes); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", models.ErrInvalidAgeGroups, path, err)
+	}
+	if err := schemes.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &schemes, nil
+}
+
+// DefaultAgeGroupSchemes возвращает набор из одной схемы по умолчанию
+func DefaultAgeGroupSchemes() *AgeGroupSchemes {
+	return &AgeGroupSchemes{
+		Default: DefaultAgeScheme,
+		Schemes: map[string]*AgeGroupRules{DefaultAgeScheme: DefaultAgeGroupRules()},
+		Labels:  DefaultLabelCatalog(),
+	}
+}
+
+// compile проверяет все схемы набора
+func (s *AgeGroupSchemes) compile() error {
+	if len(s.Schemes) == 0 {
+		return fmt.Errorf("%w: нет ни одной схемы", models.ErrInvalidAgeGroups)
+	}
+
+	for name, rules := range s.Schemes {
+		if name == "" || rules == nil {
+			return fmt.Errorf("%w: схема без названия или диапазонов", models.ErrInvalidAgeGroups)
+		}
+		if err := rules.compile(); err != nil {
+			return fmt.Errorf("схема %q: %w", name, err)
+		}
+		rules.Name = name
+	}
+
+	if _, ok := s.Schemes[s.Default]; !ok {
+		return fmt.Errorf("%w: схема по умолчанию %q не найдена", models.ErrInvalidAgeGroups, s.Default)
+	}
+	return nil
+}
+
+// Get возвращает схему по имени; пустое имя означает схему по умолчанию
+func (s *AgeGroupSchemes) Get(name string) (*AgeGroupRules, error) {
+	if name == "" {
+		name = s.Default
+	}
+	rules, ok := s.Schemes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", models.ErrUnknownAgeScheme, name)
+	}
+	return rules, nil
+}
+
+// DefaultAgeGroupRules возвращает таблицу по умолчанию: до 25, от 25 до 35, старше 35
+func DefaultAgeGroupRules() *AgeGroupRules {
+	rules, err := NewAgeGroupRules([]AgeRange{
+		{Max: intPtr(25), MaxExclusive: true, Code: AgeGroupCodeYang, Label: AgeGroupYang},
+		{Min: intPtr(25), Max: intPtr(35), Code: AgeGroupCodeMiddle, Label: AgeGroupMiddle},
+		{Min: intPtr(35), MinExclusive: true, Code: AgeGroupCodeOld, Label: AgeGroupOld},
+	})
+	if err != nil {
+		panic(err)
+	}
+	rules.Name = DefaultAgeScheme
+	return rules
+}
+
+// compile проверяет таблицу и подготавливает ее к поиску
+func (r *AgeGroupRules) compile() error {
+	if len(r.Ranges) == 0 {
+		return fmt.Errorf("%w: нет ни одного диапазона", models.ErrInvalidAgeGroups)
+	}
+
+	lows := make([]int, len(r.Ranges))
+	codes := make(map[string]struct{}, len(r.Ranges))
+	prevHigh := 0
+	for i, ageRange := range r.Ranges {
+		if ageRange.Label == "" {
+			return fmt.Errorf("%w: диапазон #%d без названия", models.ErrInvalidAgeGroups, i)
+		}
+		if ageRange.Code == "" {
+			return fmt.Errorf("%w: диапазон %q без кода", models.ErrInvalidAgeGroups, ageRange.Label)
+		}
+		if _, ok := codes[ageRange.Code]; ok {
+			return fmt.Errorf("%w: код %q повторяется", models.ErrInvalidAgeGroups, ageRange.Code)
+		}
+		codes[ageRange.Code] = struct{}{}
+
+		low, high := ageRange.bounds()
+		if low > high {
+			return fmt.Errorf("%w: диапазон %q пуст", models.ErrInvalidAgeGroups, ageRange.Label)
+		}
+
+		switch {
+		case i == 0 && low != math.MinInt:
+			return fmt.Errorf("%w: первый диапазон %q должен быть без нижней границы", models.ErrInvalidAgeGroups, ageRange.Label)
+		case i > 0 && low <= prevHigh:
+			return fmt.Errorf("%w: диапазон %q пересекается с %q", models.ErrInvalidAgeGroups, ageRange.Label, r.Ranges[i-1].Label)
+		case i > 0 && low != prevHigh+1:
+			return fmt.Errorf("%w: пропуск между %q и %q", models.ErrInvalidAgeGroups, r.Ranges[i-1].Label, ageRange.Label)
+		}
+
+		lows[i] = low
+		prevHigh = high
+	}
+
+	if prevHigh != math.MaxInt {
+		return fmt.Errorf("%w: последний диапазон %q должен быть без верхней границы", models.ErrInvalidAgeGroups, r.Ranges[len(r.Ranges)-1].Label)
+	}
+
+	r.lows = lows
+	return nil
+}
+
+// Find возвращает диапазон, в который попадает возраст
+func (r *AgeGroupRules) Find(age int) AgeRange {
+	i := sort.Search(len(r.lows), func(i int) bool { return r.lows[i] > age }) - 1
+	return r.Ranges[i]
+}
+
+// Group возвращает название группы для возраста
+func (r *AgeGroupRules) Group(age int) string {
+	return r.Find(age).Label
+}
+
+// GetAgeGroup - функция для определения возрастной группы на языке конвертера
 func (c *Converter) GetAgeGroup(age int) string {
-	switch {
-	case age < 25:
-		return AgeGroupYang
-	case age >= 25 && age <= 35:
-		return AgeGroupMiddle
-	default:
-		return AgeGroupOld
+	return c.labels.Label(c.language, c.ageGroups.Find(age))
+}
+
+// GetAgeGroupCode возвращает машинный код возрастной группы
+func (c *Converter) GetAgeGroupCode(age int) string {
+	return c.ageGroups.Find(age).Code
+}
+
+// AgeScheme возвращает название используемой схемы возрастных групп
+func (c *Converter) AgeScheme() string {
+	return c.ageGroups.Name
+}
+
+// ForAgeScheme возвращает копию конвертера, использующую схему name.
+// Пустое имя означает схему по умолчанию.
+func (c *Converter) ForAgeScheme(name string) (*Converter, error) {
+	rules, err := c.ageSchemes.Get(name)
+	if err != nil {
+		return nil, err
 	}
+
+	scoped := *c
+	scoped.ageGroups = rules
+	return &scoped, nil
+}
+
+func intPtr(v int) *int {
+	return &v
 }
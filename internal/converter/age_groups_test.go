@@ -1,9 +1,14 @@
 package converter
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/NarthurN/GoXML_JSON/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConverter_GetAgeGroup(t *testing.T) {
@@ -158,6 +163,255 @@ func TestConverter_GetAgeGroup_AllGroups(t *testing.T) {
 	}
 }
 
+func TestAgeGroupRules_Custom(t *testing.T) {
+	// Маркетинговые группы 18/30/45/60
+	rules, err := NewAgeGroupRules([]AgeRange{
+		{Max: intPtr(18), MaxExclusive: true, Code: "lt18", Label: "до 18"},
+		{Min: intPtr(18), Max: intPtr(30), MaxExclusive: true, Code: "18_29", Label: "18-29"},
+		{Min: intPtr(30), Max: intPtr(45), MaxExclusive: true, Code: "30_44", Label: "30-44"},
+		{Min: intPtr(45), Max: intPtr(60), MaxExclusive: true, Code: "45_59", Label: "45-59"},
+		{Min: intPtr(60), Code: "ge60", Label: "60+"},
+	})
+	require.NoError(t, err)
+
+	converter := NewConverter(WithAgeGroupRules(rules))
+
+	tests := []struct {
+		age      int
+		expected string
+	}{
+		{-1, "до 18"},
+		{17, "до 18"},
+		{18, "18-29"},
+		{29, "18-29"},
+		{30, "30-44"},
+		{44, "30-44"},
+		{45, "45-59"},
+		{59, "45-59"},
+		{60, "60+"},
+		{1000, "60+"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, converter.GetAgeGroup(tt.age), "Возраст: %d", tt.age)
+	}
+}
+
+func TestAgeGroupRules_FiveYearBands(t *testing.T) {
+	ranges := []AgeRange{{Max: intPtr(4), Code: "0_4", Label: "0-4"}}
+	for low := 5; low < 100; low += 5 {
+		ranges = append(ranges, AgeRange{Min: intPtr(low), Max: intPtr(low + 4), Code: fmt.Sprintf("%d_%d", low, low+4), Label: fmt.Sprintf("%d-%d", low, low+4)})
+	}
+	ranges = append(ranges, AgeRange{Min: intPtr(100), Code: "ge100", Label: "100+"})
+
+	rules, err := NewAgeGroupRules(ranges)
+	require.NoError(t, err)
+
+	assert.Equal(t, "0-4", rules.Group(0))
+	assert.Equal(t, "5-9", rules.Group(5))
+	assert.Equal(t, "30-34", rules.Group(34))
+	assert.Equal(t, "95-99", rules.Group(99))
+	assert.Equal(t, "100+", rules.Group(100))
+}
+
+func TestAgeGroupRules_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []AgeRange
+	}{
+		{
+			name:   "пустая таблица",
+			ranges: nil,
+		},
+		{
+			name: "диапазон без названия",
+			ranges: []AgeRange{
+				{Max: intPtr(25), Code: "le25", Label: "до 25"},
+				{Min: intPtr(25), MinExclusive: true},
+			},
+		},
+		{
+			name: "диапазон без кода",
+			ranges: []AgeRange{
+				{Max: intPtr(25), Code: "le25", Label: "до 25"},
+				{Min: intPtr(25), MinExclusive: true, Label: "старше 25"},
+			},
+		},
+		{
+			name: "повторяющийся код",
+			ranges: []AgeRange{
+				{Max: intPtr(25), Code: "group", Label: "до 25"},
+				{Min: intPtr(25), MinExclusive: true, Code: "group", Label: "старше 25"},
+			},
+		},
+		{
+			name: "пересечение диапазонов",
+			ranges: []AgeRange{
+				{Max: intPtr(25), Code: "le25", Label: "до 25"},
+				{Min: intPtr(25), Code: "ge25", Label: "от 25"},
+			},
+		},
+		{
+			name: "пропуск между диапазонами",
+			ranges: []AgeRange{
+				{Max: intPtr(25), MaxExclusive: true, Code: "lt25", Label: "до 25"},
+				{Min: intPtr(25), MinExclusive: true, Code: "gt25", Label: "старше 25"},
+			},
+		},
+		{
+			name: "первый диапазон с нижней границей",
+			ranges: []AgeRange{
+				{Min: intPtr(0), Max: intPtr(25), Code: "0_25", Label: "0-25"},
+				{Min: intPtr(25), MinExclusive: true, Code: "gt25", Label: "старше 25"},
+			},
+		},
+		{
+			name: "последний диапазон с верхней границей",
+			ranges: []AgeRange{
+				{Max: intPtr(25), Code: "le25", Label: "до 25"},
+				{Min: intPtr(25), MinExclusive: true, Max: intPtr(110), Code: "26_110", Label: "26-110"},
+			},
+		},
+		{
+			name: "пустой диапазон",
+			ranges: []AgeRange{
+				{Max: intPtr(25), Code: "le25", Label: "до 25"},
+				{Min: intPtr(26), Max: intPtr(26), MaxExclusive: true, Code: "empty", Label: "пусто"},
+				{Min: intPtr(26), Code: "ge26", Label: "от 26"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewAgeGroupRules(tt.ranges)
+
+			assert.ErrorIs(t, err, models.ErrInvalidAgeGroups)
+			assert.Nil(t, rules)
+		})
+	}
+}
+
+func TestLoadAgeGroupRules(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("корректный файл", func(t *testing.T) {
+		path := filepath.Join(dir, "age_groups.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"ranges": [
+			{"max": 30, "max_exclusive": true, "code": "young", "label": "молодые"},
+			{"min": 30, "code": "adult", "label": "взрослые"}
+		]}`), 0644))
+
+		rules, err := LoadAgeGroupRules(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "молодые", rules.Group(29))
+		assert.Equal(t, "взрослые", rules.Group(30))
+	})
+
+	t.Run("некорректная таблица", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"ranges": [{"max": 30, "label": "до 30"}]}`), 0644))
+
+		_, err := LoadAgeGroupRules(path)
+
+		assert.ErrorIs(t, err, models.ErrInvalidAgeGroups)
+	})
+
+	t.Run("некорректный JSON", func(t *testing.T) {
+		path := filepath.Join(dir, "broken.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"ranges": [`), 0644))
+
+		_, err := LoadAgeGroupRules(path)
+
+		assert.ErrorIs(t, err, models.ErrInvalidAgeGroups)
+	})
+
+	t.Run("файл не найден", func(t *testing.T) {
+		_, err := LoadAgeGroupRules(filepath.Join(dir, "missing.json"))
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestLoadAgeGroupSchemes(t *testing.T) {
+	t.Run("конфигурация из репозитория", func(t *testing.T) {
+		schemes, err := LoadAgeGroupSchemes(filepath.Join("..", "..", "age_groups.json"))
+
+		require.NoError(t, err)
+		assert.Equal(t, DefaultAgeScheme, schemes.Default)
+		assert.Contains(t, schemes.Schemes, "marketing")
+		assert.Contains(t, schemes.Schemes, "insurance")
+	})
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "нет схем",
+			data: `{"default": "default", "schemes": {}}`,
+		},
+		{
+			name: "схема по умолчанию не найдена",
+			data: `{"default": "main", "schemes": {"other": {"ranges": [{"label": "все"}]}}}`,
+		},
+		{
+			name: "некорректная схема",
+			data: `{"default": "main", "schemes": {"main": {"ranges": [{"max": 10, "label": "до 10"}]}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "age_groups.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.data), 0644))
+
+			_, err := LoadAgeGroupSchemes(path)
+
+			assert.ErrorIs(t, err, models.ErrInvalidAgeGroups)
+		})
+	}
+}
+
+func TestConverter_ForAgeScheme(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "age_groups.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default": "standard",
+		"schemes": {
+			"standard": {"ranges": [
+				{"max": 25, "max_exclusive": true, "code": "lt25", "label": "до 25"},
+				{"min": 25, "code": "ge25", "label": "от 25"}
+			]},
+			"marketing": {"ranges": [
+				{"max": 18, "max_exclusive": true, "code": "lt18", "label": "до 18"},
+				{"min": 18, "code": "ge18", "label": "18+"}
+			]}
+		}
+	}`), 0644))
+
+	schemes, err := LoadAgeGroupSchemes(path)
+	require.NoError(t, err)
+
+	converter := NewConverter(WithAgeGroupSchemes(schemes))
+	assert.Equal(t, "standard", converter.AgeScheme())
+
+	marketing, err := converter.ForAgeScheme("marketing")
+	require.NoError(t, err)
+	assert.Equal(t, "marketing", marketing.AgeScheme())
+	assert.Equal(t, "18+", marketing.GetAgeGroup(20))
+
+	// Исходный конвертер не меняется
+	assert.Equal(t, "до 25", converter.GetAgeGroup(20))
+
+	standard, err := converter.ForAgeScheme("")
+	require.NoError(t, err)
+	assert.Equal(t, "standard", standard.AgeScheme())
+
+	_, err = converter.ForAgeScheme("unknown")
+	assert.ErrorIs(t, err, models.ErrUnknownAgeScheme)
+}
+
 // Benchmark тест для проверки производительности
 func BenchmarkConverter_GetAgeGroup(b *testing.B) {
 	converter := NewConverter()
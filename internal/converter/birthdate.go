@@ -0,0 +1,68 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// BirthDateLayouts - поддерживаемые форматы даты рождения
+var BirthDateLayouts = []string{
+	"2006-01-02",
+	"02.01.2006",
+	"02/01/2006",
+	"2006/01/02",
+	"20060102",
+	time.RFC3339,
+}
+
+// ParseBirthDate разбирает дату рождения в одном из форматов BirthDateLayouts
+func ParseBirthDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range BirthDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return civilDate(date), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", models.ErrInvalidBirthDate, value)
+}
+
+// AgeAt возвращает число полных лет на дату asOf
+func AgeAt(birth, asOf time.Time) int {
+	birth, asOf = civilDate(birth), civilDate(asOf)
+
+	age := asOf.Year() - birth.Year()
+	if asOf.Month() < birth.Month() || (asOf.Month() == birth.Month() && asOf.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// ageFromBirthDate вычисляет возраст по дате рождения относительно даты расчета конвертера
+func (c *Converter) ageFromBirthDate(value string) (int, error) {
+	birth, err := ParseBirthDate(value)
+	if err != nil {
+		return 0, err
+	}
+
+	asOf := civilDate(c.asOfDate())
+	if birth.After(asOf) {
+		return 0, fmt.Errorf("%w: %s", models.ErrFutureBirthDate, birth.Format(time.DateOnly))
+	}
+	return AgeAt(birth, asOf), nil
+}
+
+// asOfDate возвращает дату, на которую рассчитывается возраст
+func (c *Converter) asOfDate() time.Time {
+	if c.asOf.IsZero() {
+		return time.Now()
+	}
+	return c.asOf
+}
+
+// civilDate отбрасывает время и часовой пояс, оставляя календарную дату
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,108 @@
+package converter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBirthDate(t *testing.T) {
+	expected := time.Date(1990, time.April, 12, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"ISO 8601", "1990-04-12"},
+		{"через точку", "12.04.1990"},
+		{"через слэш", "12/04/1990"},
+		{"год впереди через слэш", "1990/04/12"},
+		{"без разделителей", "19900412"},
+		{"RFC 3339", "1990-04-12T10:30:00+05:00"},
+		{"с пробелами", "  1990-04-12  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, err := ParseBirthDate(tt.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, date)
+		})
+	}
+
+	for _, value := range []string{"", "12 апреля 1990", "1990-13-01", "31.02.1990"} {
+		_, err := ParseBirthDate(value)
+		assert.ErrorIs(t, err, models.ErrInvalidBirthDate, "значение %q", value)
+	}
+}
+
+func TestAgeAt(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		birth    time.Time
+		asOf     time.Time
+		expected int
+	}{
+		{"день рождения сегодня", date(1990, time.April, 12), date(2020, time.April, 12), 30},
+		{"день рождения завтра", date(1990, time.April, 12), date(2020, time.April, 11), 29},
+		{"день рождения прошел", date(1990, time.April, 12), date(2020, time.December, 31), 30},
+		{"29 февраля в невисокосный год", date(2000, time.February, 29), date(2021, time.February, 28), 20},
+		{"29 февраля, 1 марта", date(2000, time.February, 29), date(2021, time.March, 1), 21},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AgeAt(tt.birth, tt.asOf))
+		})
+	}
+}
+
+func TestConverter_UsersXMLToJSON_BirthDate(t *testing.T) {
+	converter := NewConverter(WithAsOfDate(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)))
+
+	users := &models.XMLUsers{
+		Users: []models.XMLUser{
+			{ID: "1", Name: "Иван", Email: "ivan@example.com", BirthDate: "1990-04-12"},
+			{ID: "2", Name: "Мария", Email: "maria@example.com", BirthDate: "01.07.2001"},
+			{ID: "3", Name: "Петр", Email: "petr@example.com", Age: 20, BirthDate: "1980-01-01"},
+			{ID: "4", Name: "Будущий", Email: "future@example.com", BirthDate: "2030-01-01"},
+			{ID: "5", Name: "Старый", Email: "old@example.com", BirthDate: "1900-01-01"},
+			{ID: "6", Name: "Ошибка", Email: "bad@example.com", BirthDate: "вчера"},
+		},
+	}
+
+	result, err := converter.UsersXMLToJSON(users)
+
+	require.Len(t, result, 3)
+	assert.Equal(t, AgeGroupMiddle, result[0].AgeGroup) // 35 полных лет
+	assert.Equal(t, AgeGroupYang, result[1].AgeGroup)   // 24 года ровно в день рождения
+	assert.Equal(t, AgeGroupOld, result[2].AgeGroup)    // дата рождения важнее поля age
+	assert.ErrorIs(t, err, models.ErrFutureBirthDate)
+	assert.ErrorIs(t, err, models.ErrInvalidAge)
+	assert.ErrorIs(t, err, models.ErrInvalidBirthDate)
+}
+
+func TestConverter_ParseXML_BirthDate(t *testing.T) {
+	converter := NewConverter()
+
+	result, err := converter.ParseXML([]byte(`<users>
+    <user id="1">
+        <name>Иван Иванов</name>
+        <email>ivan@example.com</email>
+        <birthdate>1990-04-12</birthdate>
+    </user>
+</users>`))
+
+	require.NoError(t, err)
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, "1990-04-12", result.Users[0].BirthDate)
+	assert.Equal(t, 0, result.Users[0].Age)
+}
@@ -0,0 +1,133 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsets - поддерживаемые кодировки входного XML по названиям (в нижнем регистре)
+var charsets = map[string]encoding.Encoding{
+	"utf-8":        encoding.Nop,
+	"utf8":         encoding.Nop,
+	"us-ascii":     encoding.Nop,
+	"ascii":        encoding.Nop,
+	"windows-1251": charmap.Windows1251,
+	"cp1251":       charmap.Windows1251,
+	"x-cp1251":     charmap.Windows1251,
+	"koi8-r":       charmap.KOI8R,
+	"koi8r":        charmap.KOI8R,
+	"cp866":        charmap.CodePage866,
+	"ibm866":       charmap.CodePage866,
+	"866":          charmap.CodePage866,
+	"utf-16":       unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+}
+
+// Метки порядка байтов
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+)
+
+// lookupCharset возвращает кодировку по названию
+func lookupCharset(charset string) (encoding.Encoding, error) {
+	enc, ok := charsets[strings.ToLower(strings.TrimSpace(charset))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", models.ErrUnsupportedCharset, charset)
+	}
+	return enc, nil
+}
+
+// ValidateCharset проверяет, что кодировка поддерживается; пустая строка допустима
+func ValidateCharset(charset string) error {
+	if charset == "" {
+		return nil
+	}
+	_, err := lookupCharset(charset)
+	return err
+}
+
+// ForCharset возвращает копию конвертера, читающую документы в кодировке charset
+// (например, из параметра charset заголовка Content-Type). Она важнее объявления
+// <?xml encoding="..."?>. Пустая строка означает кодировку из объявления.
+func (c *Converter) ForCharset(charset string) (*Converter, error) {
+	if err := ValidateCharset(charset); err != nil {
+		return nil, err
+	}
+
+	scoped := *c
+	scoped.charset = charset
+	return &scoped, nil
+}
+
+// Charset возвращает кодировку документов, заданную для конвертера ("" - из объявления XML)
+func (c *Converter) Charset() string {
+	return c.charset
+}
+
+// CharsetReader перекодирует в UTF-8 документ в кодировке из объявления <?xml encoding="..."?>.
+// Подходит для xml.Decoder.CharsetReader. UTF-16 определяется только по BOM:
+// раз объявление удалось прочитать, документ записан однобайтовой кодировкой.
+func CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if strings.HasPrefix(strings.ToLower(charset), "utf-16") {
+		return nil, fmt.Errorf("%w: %s без BOM", models.ErrUnsupportedCharset, charset)
+	}
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewReader(input, enc.NewDecoder()), nil
+}
+
+// newXMLDecoder создает декодер, перекодирующий документ в UTF-8 и проверяющий ограничения.
+// Кодировка определяется по BOM, затем по charset, затем по объявлению XML.
+func newXMLDecoder(r io.Reader, charset string, limits Limits) *xmlDecoder {
+	input, decoded := decodeCharset(r, charset)
+	reader := newPositionReader(input)
+	decoder := xml.NewDecoder(reader)
+	if decoded {
+		// Документ уже в UTF-8, объявление encoding больше не действует
+		decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	} else {
+		decoder.CharsetReader = reader.charsetReader
+	}
+	return newLimitedDecoder(decoder, reader, limits)
+}
+
+// decodeCharset перекодирует r в UTF-8 по BOM или кодировке charset.
+// Возвращает false, если кодировка не определена и ее нужно взять из объявления XML.
+func decodeCharset(r io.Reader, charset string) (io.Reader, bool) {
+	input := bufio.NewReader(r)
+	prefix, _ := input.Peek(len(bomUTF8))
+
+	switch {
+	case bytes.HasPrefix(prefix, bomUTF8):
+		input.Discard(len(bomUTF8))
+		return input, true
+	case bytes.HasPrefix(prefix, bomUTF16BE), bytes.HasPrefix(prefix, bomUTF16LE):
+		// ExpectBOM: порядок байтов берется из BOM, сама метка отбрасывается
+		return transform.NewReader(input, unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()), true
+	}
+
+	if charset == "" {
+		return input, false
+	}
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		// Неизвестная кодировка отклоняется в ForCharset, остается объявление XML
+		return input, false
+	}
+	return transform.NewReader(input, enc.NewDecoder()), true
+}
@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const charsetUsers = `<users>
+    <user id="1"><name>Иван Петров</name><email>ivan@example.com</email><age>30</age></user>
+    <user id="2"><name>Ёлка Жукова</name><email>elka@example.com</email><age>25</age></user>
+</users>`
+
+// encodeXML кодирует документ с объявлением encoding в кодировку enc
+func encodeXML(t *testing.T, enc encoding.Encoding, declaration string) []byte {
+	t.Helper()
+	data, err := enc.NewEncoder().Bytes([]byte(declaration + charsetUsers))
+	require.NoError(t, err)
+	return data
+}
+
+func TestConverter_UsersXMLStreamToJSON_Charsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		charset string
+	}{
+		{
+			name: "windows-1251 из объявления",
+			data: encodeXML(t, charmap.Windows1251, `<?xml version="1.0" encoding="windows-1251"?>`),
+		},
+		{
+			name: "KOI8-R из объявления",
+			data: encodeXML(t, charmap.KOI8R, `<?xml version="1.0" encoding="KOI8-R"?>`),
+		},
+		{
+			name: "CP866 из объявления",
+			data: encodeXML(t, charmap.CodePage866, `<?xml version="1.0" encoding="cp866"?>`),
+		},
+		{
+			name:    "windows-1251 из Content-Type без объявления",
+			data:    encodeXML(t, charmap.Windows1251, ""),
+			charset: "Windows-1251",
+		},
+		{
+			name:    "Content-Type важнее объявления",
+			data:    encodeXML(t, charmap.KOI8R, `<?xml version="1.0" encoding="windows-1251"?>`),
+			charset: "koi8-r",
+		},
+		{
+			name: "UTF-16LE с BOM",
+			data: encodeXML(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), `<?xml version="1.0" encoding="UTF-16"?>`),
+		},
+		{
+			name: "UTF-16BE с BOM",
+			data: encodeXML(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), `<?xml version="1.0" encoding="UTF-16"?>`),
+		},
+		{
+			name: "UTF-8 с BOM",
+			data: append([]byte{0xEF, 0xBB, 0xBF}, `<?xml version="1.0" encoding="UTF-8"?>`+charsetUsers...),
+		},
+		{
+			name:    "UTF-16LE из Content-Type без BOM",
+			data:    encodeXML(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), ""),
+			charset: "utf-16le",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter, err := NewConverter().ForCharset(tt.charset)
+			require.NoError(t, err)
+
+			result, err := converter.UsersXMLStreamToJSON(bytes.NewReader(tt.data))
+
+			require.NoError(t, err)
+			require.Len(t, result, 2)
+			assert.Equal(t, "Иван Петров", result[0].FullName)
+			assert.Equal(t, "Ёлка Жукова", result[1].FullName)
+		})
+	}
+}
+
+func TestConverter_Charsets_Errors(t *testing.T) {
+	t.Run("неизвестная кодировка в объявлении", func(t *testing.T) {
+		data := []byte(`<?xml version="1.0" encoding="iso-2022-jp"?>` + charsetUsers)
+
+		_, err := NewConverter().UsersXMLStreamToJSON(bytes.NewReader(data))
+
+		assert.ErrorIs(t, err, models.ErrInvalidXML)
+		assert.ErrorIs(t, err, models.ErrUnsupportedCharset)
+	})
+
+	t.Run("UTF-16 в объявлении без BOM", func(t *testing.T) {
+		data := []byte(`<?xml version="1.0" encoding="UTF-16"?>` + charsetUsers)
+
+		_, err := NewConverter().UsersXMLStreamToJSON(bytes.NewReader(data))
+
+		assert.ErrorIs(t, err, models.ErrUnsupportedCharset)
+	})
+
+	t.Run("неизвестная кодировка в Content-Type", func(t *testing.T) {
+		_, err := NewConverter().ForCharset("latin-42")
+
+		assert.ErrorIs(t, err, models.ErrUnsupportedCharset)
+	})
+}
+
+func TestConverter_ValidateSchema_Charset(t *testing.T) {
+	schema, err := ParseSchema([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="users">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="user" maxOccurs="unbounded">
+          <xs:complexType>
+            <xs:sequence>
+              <xs:element name="name" type="xs:string"/>
+              <xs:element name="email" type="xs:string"/>
+              <xs:element name="age" type="xs:positiveInteger"/>
+            </xs:sequence>
+            <xs:attribute name="id" type="xs:string" use="required"/>
+          </xs:complexType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`))
+	require.NoError(t, err)
+	converter, err := NewConverter(WithSchema(schema)).ForCharset("cp866")
+	require.NoError(t, err)
+
+	assert.NoError(t, converter.ValidateSchema(bytes.NewReader(encodeXML(t, charmap.CodePage866, ""))))
+	assert.NoError(t, schema.Validate(bytes.NewReader(encodeXML(t, charmap.Windows1251, `<?xml version="1.0" encoding="windows-1251"?>`))))
+}
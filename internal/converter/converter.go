@@ -1,7 +1,91 @@
 package converter
 
-type Converter struct{}
+import "time"
 
-func NewConverter() *Converter {
-	return &Converter{}
+type Converter struct {
+	ageGroups   *AgeGroupRules      // Текущая схема возрастных групп
+	ageSchemes  *AgeGroupSchemes    // Все доступные схемы
+	labels      LabelCatalog        // Переводы названий групп
+	language    string              // Язык названий групп
+	asOf        time.Time           // Дата расчета возраста по дате рождения (нулевая - сегодня)
+	mapping     *Mapping            // Текущий маппинг полей (nil - стандартный формат)
+	mappings    map[string]*Mapping // Все доступные маппинги
+	passthrough string              // Режим переноса нераспознанных элементов в JSON
+	namespaces  map[string]struct{} // Разрешенные пространства имен (nil - любые)
+	schema      *Schema             // XSD схема для проверки документа (nil - без проверки)
+
+	blockedDomains map[string]struct{} // Запрещенные домены email (punycode)
+	duplicates     string              // Политика обработки повторяющихся ID
+	titleCase      bool                // Приводить имена к виду «Иван Петров»
+	homoglyphs     bool                // Предупреждать о смешении алфавитов в имени
+	charset        string              // Кодировка документов ("" - из BOM или объявления XML)
+	limits         Limits              // Ограничения на входной документ
+	xmlOutput      XMLOutput           // Формат документов обратного преобразования
+}
+
+// Option - функциональная опция для настройки конвертера
+type Option func(*Converter)
+
+// WithAgeGroupRules задает единственную таблицу возрастных групп
+func WithAgeGroupRules(rules *AgeGroupRules) Option {
+	return func(c *Converter) {
+		if rules.Name == "" {
+			rules.Name = DefaultAgeScheme
+		}
+		c.ageGroups = rules
+		c.ageSchemes = &AgeGroupSchemes{
+			Default: rules.Name,
+			Schemes: map[string]*AgeGroupRules{rules.Name: rules},
+		}
+	}
+}
+
+// WithAgeGroupSchemes задает набор именованных схем возрастных групп
+func WithAgeGroupSchemes(schemes *AgeGroupSchemes) Option {
+	return func(c *Converter) {
+		c.ageSchemes = schemes
+		c.ageGroups = schemes.Schemes[schemes.Default]
+		c.labels = schemes.Labels
+	}
+}
+
+// WithLanguage задает язык названий возрастных групп
+func WithLanguage(lang string) Option {
+	return func(c *Converter) {
+		c.language = lang
+	}
+}
+
+// WithAsOfDate задает дату, на которую возраст вычисляется по дате рождения
+func WithAsOfDate(asOf time.Time) Option {
+	return func(c *Converter) {
+		c.asOf = asOf
+	}
+}
+
+// WithMappings задает доступные маппинги форматов XML
+func WithMappings(mappings map[string]*Mapping) Option {
+	return func(c *Converter) {
+		c.mappings = mappings
+	}
+}
+
+func NewConverter(opts ...Option) *Converter {
+	schemes := DefaultAgeGroupSchemes()
+	c := &Converter{
+		ageGroups:  schemes.Schemes[schemes.Default],
+		ageSchemes: schemes,
+		labels:     schemes.Labels,
+		language:   DefaultLanguage,
+		duplicates: DuplicateKeepFirst,
+		limits:     DefaultLimits(),
+		xmlOutput:  DefaultXMLOutput(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if !c.labels.Supports(c.language) {
+		c.language = DefaultLanguage
+	}
+	return c
 }
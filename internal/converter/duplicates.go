@@ -0,0 +1,274 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Политики обработки записей с повторяющимся ID
+const (
+	DuplicateKeepFirst = "keep-first" // Остается первая запись
+	DuplicateKeepLast  = "keep-last"  // Остается последняя запись
+	DuplicateReject    = "reject"     // Отклоняются все записи с этим ID
+	DuplicateMerge     = "merge"      // Записи объединяются в одну на месте первой
+)
+
+// WithDuplicatePolicy задает политику обработки дубликатов; пустая строка - keep-first
+func WithDuplicatePolicy(policy string) Option {
+	return func(c *Converter) {
+		if policy != "" {
+			c.duplicates = policy
+		}
+	}
+}
+
+// ValidateDuplicatePolicy проверяет название политики обработки дубликатов
+func ValidateDuplicatePolicy(policy string) error {
+	switch policy {
+	case "", DuplicateKeepFirst, DuplicateKeepLast, DuplicateReject, DuplicateMerge:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", models.ErrInvalidDuplicatePolicy, policy)
+}
+
+// ForDuplicatePolicy возвращает копию конвертера с политикой обработки дубликатов.
+// Пустая строка означает политику из настроек.
+func (c *Converter) ForDuplicatePolicy(policy string) (*Converter, error) {
+	if err := ValidateDuplicatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	scoped := *c
+	if policy != "" {
+		scoped.duplicates = policy
+	}
+	return &scoped, nil
+}
+
+// DuplicatePolicy возвращает политику обработки дубликатов.
+// В потоковом режиме (ConvertStream) доступна только keep-first:
+// остальным политикам нужен весь пакет.
+func (c *Converter) DuplicatePolicy() string {
+	return c.duplicates
+}
+
+// indexedUser - сконвертированный пользователь с номером записи в документе
+type indexedUser struct {
+	index int
+	user  models.JSONUser
+}
+
+// resolveDuplicates применяет политику обработки дубликатов ко всему пакету.
+// Возвращает пользователей в порядке документа и описания найденных дубликатов.
+func (c *Converter) resolveDuplicates(users []indexedUser) ([]models.JSONUser, []error) {
+	positions := make(map[string][]int, len(users))
+	var ids []string
+	for i, u := range users {
+		if _, ok := positions[u.user.ID]; !ok {
+			ids = append(ids, u.user.ID)
+		}
+		positions[u.user.ID] = append(positions[u.user.ID], i)
+	}
+
+	dropped := make([]bool, len(users))
+	var duplicates []error
+	for _, id := range ids {
+		group := positions[id]
+		if len(group) < 2 {
+			continue
+		}
+
+		kept := -1
+		switch c.duplicates {
+		case DuplicateKeepLast:
+			kept = group[len(group)-1]
+		case DuplicateReject:
+		default:
+			kept = group[0]
+		}
+
+		duplicate := &models.DuplicateError{UserID: id, Kept: -1, Policy: c.duplicates}
+		for _, p := range group {
+			duplicate.Indexes = append(duplicate.Indexes, users[p].index)
+			if p == kept {
+				duplicate.Kept = users[p].index
+			} else {
+				dropped[p] = true
+			}
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	result := make([]models.JSONUser, 0, len(users))
+	for i, u := range users {
+		if !dropped[i] {
+			result = append(result, u.user)
+		}
+	}
+	return result, duplicates
+}
+
+// mergedRecord - запись документа, в которую влиты более поздние записи с тем же ID
+type mergedRecord struct {
+	index   int
+	user    models.XMLUser
+	indexes []int // Номера всех объединенных записей
+}
+
+// collectMerged реализует политику merge. Сырые записи с одинаковым ID объединяются
+// до валидации, поэтому несколько неполных записей могут дать одного валидного пользователя.
+// Объединенная запись занимает место первой, ее пустые поля заполняются из более поздних.
+// Записи держатся в памяти целиком: объединить их можно только после чтения всего документа.
+func (c *Converter) collectMerged(next recordSource) ([]models.JSONUser, int, error) {
+	var records []*mergedRecord
+	byID := make(map[string]*mergedRecord)
+	total := 0
+	for {
+		index, user, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, total, err
+		}
+		total++
+
+		id := strings.TrimSpace(user.ID)
+		if record, ok := byID[id]; ok {
+			record.user = mergeRecords(record.user, user)
+			record.indexes = append(record.indexes, index)
+			continue
+		}
+		record := &mergedRecord{index: index, user: user, indexes: []int{index}}
+		records = append(records, record)
+		if id != "" {
+			byID[id] = record
+		}
+	}
+
+	i := 0
+	var users []models.JSONUser
+	valid := make(map[int]bool, len(records))
+	_, err := c.convert(func() (int, models.XMLUser, error) {
+		if i >= len(records) {
+			return 0, models.XMLUser{}, io.EOF
+		}
+		i++
+		return records[i-1].index, records[i-1].user, nil
+	}, func(index int, user models.JSONUser) error {
+		users = append(users, user)
+		valid[index] = true
+		return nil
+	})
+
+	var duplicates []error
+	for _, record := range records {
+		if len(record.indexes) < 2 {
+			continue
+		}
+		duplicate := &models.DuplicateError{
+			UserID:  strings.TrimSpace(record.user.ID),
+			Indexes: record.indexes,
+			Kept:    -1,
+			Policy:  DuplicateMerge,
+		}
+		// Объединенная запись не прошла валидацию - не остается ни одной
+		if valid[record.index] {
+			duplicate.Kept = record.index
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	return users, total, errors.Join(append([]error{err}, duplicates...)...)
+}
+
+// mergeRecords заполняет пустые поля base значениями из next.
+// Непустые поля base не перекрываются, дополнительные поля добавляются, если их еще нет.
+func mergeRecords(base, next models.XMLUser) models.XMLUser {
+	if strings.TrimSpace(base.Name) == "" {
+		base.Name = next.Name
+	}
+	if strings.TrimSpace(base.Email) == "" {
+		base.Email = next.Email
+	}
+	if base.Age == 0 && strings.TrimSpace(base.AgeText) == "" {
+		base.Age, base.AgeText = next.Age, next.AgeText
+	}
+	if strings.TrimSpace(base.BirthDate) == "" {
+		base.BirthDate = next.BirthDate
+	}
+
+	if len(next.Extra) > 0 {
+		// Копируем, чтобы не менять карту исходной записи
+		extra := make(map[string]string, len(base.Extra)+len(next.Extra))
+		for key, value := range base.Extra {
+			extra[key] = value
+		}
+		for key, value := range next.Extra {
+			if extra[key] == "" {
+				extra[key] = value
+			}
+		}
+		base.Extra = extra
+	}
+	if len(base.UnknownAttrs) == 0 {
+		base.UnknownAttrs = next.UnknownAttrs
+	}
+	if len(base.UnknownElements) == 0 {
+		base.UnknownElements = next.UnknownElements
+	}
+	return base
+}
+
+// duplicateTracker реализует keep-first для потока: пропускает повторные ID
+// по мере поступления записей и запоминает их для отчета.
+// Хранит множество уже встреченных ID, поэтому память растет с числом уникальных пользователей.
+type duplicateTracker struct {
+	first  map[string]int   // ID -> номер первой записи
+	groups map[string][]int // Номера записей повторяющихся ID
+}
+
+func newDuplicateTracker() *duplicateTracker {
+	return &duplicateTracker{
+		first:  make(map[string]int),
+		groups: make(map[string][]int),
+	}
+}
+
+// keep сообщает, нужно ли передать запись дальше
+func (t *duplicateTracker) keep(index int, id string) bool {
+	first, ok := t.first[id]
+	if !ok {
+		t.first[id] = index
+		return true
+	}
+	if _, ok := t.groups[id]; !ok {
+		t.groups[id] = []int{first}
+	}
+	t.groups[id] = append(t.groups[id], index)
+	return false
+}
+
+// duplicates возвращает описания дубликатов в порядке первых записей
+func (t *duplicateTracker) duplicates() []error {
+	duplicates := make([]*models.DuplicateError, 0, len(t.groups))
+	for id, indexes := range t.groups {
+		duplicates = append(duplicates, &models.DuplicateError{
+			UserID:  id,
+			Indexes: indexes,
+			Kept:    indexes[0],
+			Policy:  DuplicateKeepFirst,
+		})
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Indexes[0] < duplicates[j].Indexes[0] })
+
+	errs := make([]error, len(duplicates))
+	for i, duplicate := range duplicates {
+		errs[i] = duplicate
+	}
+	return errs
+}
@@ -0,0 +1,212 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateUsers - пакет, в котором ID 1 встречается трижды, а ID 2 - дважды
+var duplicateUsers = &models.XMLUsers{
+	Users: []models.XMLUser{
+		{ID: "1", Name: "Иван", Email: "ivan@example.com", Age: 30},
+		{ID: "2", Name: "Мария", Email: "maria@example.com", Age: 25},
+		{ID: "1", Name: "Иван Иванов", Email: "ivan@example.com", Age: 31, Extra: map[string]string{"department": "IT"}},
+		{ID: "3", Name: "Петр", Email: "petr@example.com", Age: 40},
+		{ID: "2", Name: "Мария Петрова", Email: "", Age: 25},
+		{ID: "1", Name: "Иван И.", Email: "ivan.i@example.com", Age: 32},
+	},
+}
+
+func TestConverter_UsersXMLToJSON_Duplicates(t *testing.T) {
+	tests := []struct {
+		policy     string
+		ids        []string
+		names      []string
+		duplicates []models.DuplicateError
+	}{
+		{
+			policy: DuplicateKeepFirst,
+			ids:    []string{"1", "2", "3"},
+			names:  []string{"Иван", "Мария", "Петр"},
+			duplicates: []models.DuplicateError{
+				{UserID: "1", Indexes: []int{0, 2, 5}, Kept: 0, Policy: DuplicateKeepFirst},
+			},
+		},
+		{
+			policy: DuplicateKeepLast,
+			ids:    []string{"2", "3", "1"},
+			names:  []string{"Мария", "Петр", "Иван И."},
+			duplicates: []models.DuplicateError{
+				{UserID: "1", Indexes: []int{0, 2, 5}, Kept: 5, Policy: DuplicateKeepLast},
+			},
+		},
+		{
+			policy: DuplicateReject,
+			ids:    []string{"2", "3"},
+			names:  []string{"Мария", "Петр"},
+			duplicates: []models.DuplicateError{
+				{UserID: "1", Indexes: []int{0, 2, 5}, Kept: -1, Policy: DuplicateReject},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			converter, err := NewConverter().ForDuplicatePolicy(tt.policy)
+			require.NoError(t, err)
+
+			result, err := converter.UsersXMLToJSON(duplicateUsers)
+
+			var ids, names []string
+			for _, user := range result {
+				ids = append(ids, user.ID)
+				names = append(names, user.FullName)
+			}
+			assert.Equal(t, tt.ids, ids)
+			assert.Equal(t, tt.names, names)
+
+			// Запись 4 с ID 2 невалидна и в поиске дубликатов не участвует
+			assert.ErrorIs(t, err, models.ErrDuplicateID)
+			assert.ErrorIs(t, err, models.ErrEmptyEmail)
+			assert.Equal(t, tt.duplicates, DuplicateReport(err))
+			require.Len(t, ValidationReport(err), 1)
+			assert.Equal(t, 4, ValidationReport(err)[0].Index)
+		})
+	}
+}
+
+func TestConverter_UsersXMLToJSON_DuplicatesMerge(t *testing.T) {
+	converter := NewConverter(WithDuplicatePolicy(DuplicateMerge))
+
+	result, err := converter.UsersXMLToJSON(duplicateUsers)
+
+	require.Len(t, result, 3)
+	merged := result[0]
+	assert.Equal(t, "Иван", merged.FullName, "заполненные поля первой записи не перекрываются")
+	assert.Equal(t, "ivan@example.com", merged.Email)
+	assert.Equal(t, AgeGroupMiddle, merged.AgeGroup)
+	assert.Equal(t, map[string]any{"department": "IT"}, merged.Extra, "дополнительные поля сохраняются при объединении")
+	assert.Equal(t, 0, merged.Index)
+
+	// Записи объединяются до валидации: запись 4 без email влита в запись 1 и ошибкой не считается
+	assert.ErrorIs(t, err, models.ErrDuplicateID)
+	assert.Empty(t, ValidationReport(err))
+	assert.Equal(t, []models.DuplicateError{
+		{UserID: "1", Indexes: []int{0, 2, 5}, Kept: 0, Policy: DuplicateMerge},
+		{UserID: "2", Indexes: []int{1, 4}, Kept: 1, Policy: DuplicateMerge},
+	}, DuplicateReport(err))
+	assert.Empty(t, duplicateUsers.Users[0].Extra, "исходная запись не меняется")
+}
+
+func TestConverter_UsersXMLToJSON_DuplicatesMergePartial(t *testing.T) {
+	tests := []struct {
+		name          string
+		users         []models.XMLUser
+		expected      []models.JSONUser
+		duplicate     models.DuplicateError
+		expectedError error
+	}{
+		{
+			name: "неполные записи дают валидного пользователя",
+			users: []models.XMLUser{
+				{ID: "7", Name: "Анна"},
+				{ID: "8", Name: "Олег", Email: "oleg@example.com", Age: 40},
+				{ID: " 7 ", Email: "anna@example.com", Age: 28},
+			},
+			expected: []models.JSONUser{
+				{ID: "7", FullName: "Анна", Email: "anna@example.com", AgeGroup: AgeGroupMiddle, AgeGroupCode: AgeGroupCodeMiddle, Index: 0},
+				{ID: "8", FullName: "Олег", Email: "oleg@example.com", AgeGroup: AgeGroupOld, AgeGroupCode: AgeGroupCodeOld, Index: 1},
+			},
+			duplicate: models.DuplicateError{UserID: "7", Indexes: []int{0, 2}, Kept: 0, Policy: DuplicateMerge},
+		},
+		{
+			name: "объединенная запись невалидна",
+			users: []models.XMLUser{
+				{ID: "7", Name: "Анна"},
+				{ID: "7", Age: 28},
+				{ID: "8", Name: "Олег", Email: "oleg@example.com", Age: 40},
+			},
+			expected: []models.JSONUser{
+				{ID: "8", FullName: "Олег", Email: "oleg@example.com", AgeGroup: AgeGroupOld, AgeGroupCode: AgeGroupCodeOld, Index: 2},
+			},
+			duplicate:     models.DuplicateError{UserID: "7", Indexes: []int{0, 1}, Kept: -1, Policy: DuplicateMerge},
+			expectedError: models.ErrEmptyEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := NewConverter(WithDuplicatePolicy(DuplicateMerge))
+
+			result, err := converter.UsersXMLToJSON(&models.XMLUsers{Users: tt.users})
+
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, []models.DuplicateError{tt.duplicate}, DuplicateReport(err))
+			if tt.expectedError == nil {
+				assert.Empty(t, ValidationReport(err))
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedError)
+			require.Len(t, ValidationReport(err), 1)
+			assert.Equal(t, 0, ValidationReport(err)[0].Index, "ошибка относится к месту первой записи")
+		})
+	}
+}
+
+func TestConverter_ConvertStream_Duplicates(t *testing.T) {
+	xml := `<users>
+    <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+    <user id="2"><name>Мария</name><email>maria@example.com</email><age>25</age></user>
+    <user id="2"><name>Мария Петрова</name><email>maria@example.com</email><age>25</age></user>
+    <user id="1"><name>Иван Иванов</name><email>ivan@example.com</email><age>30</age></user>
+    <user id="2"><name>М.</name><email>maria@example.com</email><age>25</age></user>
+</users>`
+
+	t.Run("keep-first", func(t *testing.T) {
+		var names []string
+		total, err := NewConverter().ConvertStream(strings.NewReader(xml), func(user models.JSONUser) error {
+			names = append(names, user.FullName)
+			return nil
+		})
+
+		assert.Equal(t, 5, total)
+		assert.Equal(t, []string{"Иван", "Мария"}, names)
+		assert.Equal(t, []models.DuplicateError{
+			{UserID: "1", Indexes: []int{0, 3}, Kept: 0, Policy: DuplicateKeepFirst},
+			{UserID: "2", Indexes: []int{1, 2, 4}, Kept: 1, Policy: DuplicateKeepFirst},
+		}, DuplicateReport(err))
+		assert.Empty(t, ValidationReport(err))
+	})
+
+	t.Run("политика, требующая весь пакет", func(t *testing.T) {
+		converter := NewConverter(WithDuplicatePolicy(DuplicateReject))
+
+		_, err := converter.ConvertStream(strings.NewReader(xml), func(models.JSONUser) error {
+			t.Fatal("записи не должны передаваться")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, models.ErrDuplicatePolicyNotStreamable)
+	})
+}
+
+func TestConverter_ForDuplicatePolicy(t *testing.T) {
+	base := NewConverter(WithDuplicatePolicy(DuplicateReject))
+
+	scoped, err := base.ForDuplicatePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, DuplicateReject, scoped.DuplicatePolicy(), "пустая политика - политика из настроек")
+
+	scoped, err = base.ForDuplicatePolicy(DuplicateMerge)
+	require.NoError(t, err)
+	assert.Equal(t, DuplicateMerge, scoped.DuplicatePolicy())
+	assert.Equal(t, DuplicateReject, base.DuplicatePolicy(), "исходный конвертер не меняется")
+
+	_, err = base.ForDuplicatePolicy("keep-random")
+	assert.ErrorIs(t, err, models.ErrInvalidDuplicatePolicy)
+	assert.Equal(t, DuplicateKeepFirst, NewConverter().DuplicatePolicy())
+}
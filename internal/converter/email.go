@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"golang.org/x/net/idna"
+)
+
+// Ограничения длины адреса (RFC 5321)
+const (
+	emailMaxLength  = 254
+	emailMaxLocal   = 64
+	emailMaxDomain  = 253
+	emailMaxLabel   = 63
+	emailSpecialSet = "!#$%&'*+-/=?^_`{|}~"
+)
+
+// WithBlockedEmailDomains задает домены (например, одноразовой почты), адреса в которых отклоняются.
+// Поддомены заблокированного домена тоже отклоняются.
+func WithBlockedEmailDomains(domains ...string) Option {
+	return func(c *Converter) {
+		if len(domains) == 0 {
+			c.blockedDomains = nil
+			return
+		}
+		c.blockedDomains = make(map[string]struct{}, len(domains))
+		for _, domain := range domains {
+			if ascii, err := idna.Lookup.ToASCII(strings.TrimSpace(domain)); err == nil && ascii != "" {
+				c.blockedDomains[ascii] = struct{}{}
+			}
+		}
+	}
+}
+
+// LoadEmailDomains загружает список доменов из файла: один домен в строке, # - комментарий
+func LoadEmailDomains(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			domains = append(domains, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return domains, nil
+}
+
+// NormalizeEmail проверяет синтаксис адреса (addr-spec из RFC 5322) и нормализует его:
+// домен приводится к нижнему регистру и переводится в punycode, локальная часть не меняется.
+func NormalizeEmail(email string) (string, error) {
+	if len(email) > emailMaxLength {
+		return "", fmt.Errorf("%w: %d байт", models.ErrEmailTooLong, len(email))
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return "", fmt.Errorf("%w: %q", models.ErrInvalidEmail, email)
+	}
+	local, domain := email[:at], email[at+1:]
+
+	if len(local) > emailMaxLocal {
+		return "", fmt.Errorf("%w: локальная часть длиннее %d байт", models.ErrEmailTooLong, emailMaxLocal)
+	}
+	if !validLocalPart(local) {
+		return "", fmt.Errorf("%w: %q", models.ErrInvalidEmail, email)
+	}
+
+	ascii, err := normalizeDomain(domain)
+	if err != nil {
+		return "", err
+	}
+	return local + "@" + ascii, nil
+}
+
+// validLocalPart проверяет локальную часть: dot-atom или quoted-string.
+// Символы вне ASCII допускаются (RFC 6531).
+func validLocalPart(local string) bool {
+	if len(local) >= 2 && local[0] == '"' && local[len(local)-1] == '"' {
+		return validQuotedString(local[1 : len(local)-1])
+	}
+
+	if local[0] == '.' || local[len(local)-1] == '.' || strings.Contains(local, "..") {
+		return false
+	}
+	for _, r := range local {
+		switch {
+		case r == utf8.RuneError:
+			return false
+		case r >= utf8.RuneSelf:
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.':
+		case strings.ContainsRune(emailSpecialSet, r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validQuotedString проверяет содержимое кавычек: печатные символы и экранированные пары
+func validQuotedString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+			if i == len(s) || s[i] < ' ' || s[i] > '~' {
+				return false
+			}
+		case c == '"', c < ' ', c > '~':
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeDomain переводит домен в punycode и проверяет метки (буквы, цифры, дефис)
+func normalizeDomain(domain string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %w", models.ErrInvalidEmailDomain, domain, err)
+	}
+	if len(ascii) > emailMaxDomain {
+		return "", fmt.Errorf("%w: домен длиннее %d байт", models.ErrEmailTooLong, emailMaxDomain)
+	}
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("%w: %q: нет домена верхнего уровня", models.ErrInvalidEmailDomain, domain)
+	}
+	for _, label := range labels {
+		if !validDomainLabel(label) {
+			return "", fmt.Errorf("%w: %q", models.ErrInvalidEmailDomain, domain)
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", fmt.Errorf("%w: %q: числовой домен верхнего уровня", models.ErrInvalidEmailDomain, domain)
+	}
+	return ascii, nil
+}
+
+func validDomainLabel(label string) bool {
+	if label == "" || len(label) > emailMaxLabel || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeEmail нормализует адрес и проверяет, что его домен не заблокирован
+func (c *Converter) normalizeEmail(email string) (string, error) {
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return "", err
+	}
+
+	if c.blockedDomains != nil {
+		domain := normalized[strings.LastIndexByte(normalized, '@')+1:]
+		for {
+			if _, ok := c.blockedDomains[domain]; ok {
+				return "", fmt.Errorf("%w: %s", models.ErrBlockedEmailDomain, domain)
+			}
+			dot := strings.IndexByte(domain, '.')
+			if dot < 0 {
+				break
+			}
+			domain = domain[dot+1:]
+		}
+	}
+	return normalized, nil
+}
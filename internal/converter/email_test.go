@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		expected string
+		err      error
+	}{
+		{name: "обычный адрес", email: "ivan@example.com", expected: "ivan@example.com"},
+		{name: "домен в нижний регистр", email: "Ivan.Petrov@Example.COM", expected: "Ivan.Petrov@example.com"},
+		{name: "спецсимволы в локальной части", email: "user+tag!#$%&'*/=?^_`{|}~-@mail.example.org", expected: "user+tag!#$%&'*/=?^_`{|}~-@mail.example.org"},
+		{name: "локальная часть в кавычках", email: `"ivan ivanov"@example.com`, expected: `"ivan ivanov"@example.com`},
+		{name: "кириллический домен", email: "ivan@почта.рф", expected: "ivan@xn--80a1acny.xn--p1ai"},
+		{name: "кириллический домен в верхнем регистре", email: "ivan@ПОЧТА.РФ", expected: "ivan@xn--80a1acny.xn--p1ai"},
+		{name: "кириллическая локальная часть", email: "иван@example.com", expected: "иван@example.com"},
+
+		{name: "без @", email: "not-an-email", err: models.ErrInvalidEmail},
+		{name: "пустая локальная часть", email: "@example.com", err: models.ErrInvalidEmail},
+		{name: "пустой домен", email: "ivan@", err: models.ErrInvalidEmail},
+		{name: "две точки подряд", email: "ivan..petrov@example.com", err: models.ErrInvalidEmail},
+		{name: "точка в начале", email: ".ivan@example.com", err: models.ErrInvalidEmail},
+		{name: "пробел", email: "ivan petrov@example.com", err: models.ErrInvalidEmail},
+		{name: "незакрытые кавычки", email: `"ivan@example.com`, err: models.ErrInvalidEmail},
+		{name: "две @", email: "ivan@petrov@example.com", err: models.ErrInvalidEmail},
+
+		{name: "домен без зоны", email: "ivan@localhost", err: models.ErrInvalidEmailDomain},
+		{name: "дефис в начале метки", email: "ivan@-example.com", err: models.ErrInvalidEmailDomain},
+		{name: "пустая метка", email: "ivan@example..com", err: models.ErrInvalidEmailDomain},
+		{name: "подчеркивание в домене", email: "ivan@exa_mple.com", err: models.ErrInvalidEmailDomain},
+		{name: "числовая зона", email: "ivan@192.168.0.1", err: models.ErrInvalidEmailDomain},
+		{name: "IP литерал", email: "ivan@[192.168.0.1]", err: models.ErrInvalidEmailDomain},
+
+		{name: "длинная локальная часть", email: strings.Repeat("a", 65) + "@example.com", err: models.ErrEmailTooLong},
+		{name: "длинный адрес", email: "ivan@" + strings.Repeat("a.", 130) + "com", err: models.ErrEmailTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := NormalizeEmail(tt.email)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, email)
+		})
+	}
+}
+
+func TestConverter_UsersXMLToJSON_Email(t *testing.T) {
+	converter := NewConverter(WithBlockedEmailDomains("Mailinator.com", "yopmail.com"))
+
+	users := &models.XMLUsers{
+		Users: []models.XMLUser{
+			{ID: "1", Name: "Иван", Email: " Ivan@Example.COM ", Age: 30},
+			{ID: "2", Name: "Мария", Email: "maria@mailinator.com", Age: 25},
+			{ID: "3", Name: "Петр", Email: "petr@eu.yopmail.com", Age: 40},
+			{ID: "4", Name: "Анна", Email: "not-an-email", Age: 20},
+			{ID: "5", Name: "Ольга", Email: "olga@почта.рф", Age: 33},
+		},
+	}
+
+	result, err := converter.UsersXMLToJSON(users)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "Ivan@example.com", result[0].Email)
+	assert.Equal(t, "olga@xn--80a1acny.xn--p1ai", result[1].Email)
+
+	report := ValidationReport(err)
+	require.Len(t, report, 3)
+	assert.Equal(t, []string{models.CodeBlockedDomain, models.CodeBlockedDomain, models.CodeInvalidEmail},
+		[]string{report[0].Code, report[1].Code, report[2].Code})
+	assert.Equal(t, "email", report[2].Field)
+}
+
+func TestLoadEmailDomains(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocked.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# одноразовая почта\nmailinator.com\n\n  yopmail.com  # и поддомены\n"), 0o644))
+
+	domains, err := LoadEmailDomains(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mailinator.com", "yopmail.com"}, domains)
+
+	_, err = LoadEmailDomains(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -3,7 +3,9 @@ package converter
 import (
 	"errors"
 	"fmt"
+	"io"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -12,39 +14,150 @@ import (
 
 // job определяет задание для воркера, включая исходный индекс.
 type job struct {
-	index int
+	seq   int // Порядковый номер задания: по нему восстанавливается порядок результатов
+	index int // Номер записи в документе
 	user  models.XMLUser
 }
 
 // result содержит результат обработки одного задания.
 type result struct {
+	seq      int
 	index    int
 	jsonUser models.JSONUser
 	err      error
+	warning  error
 }
 
 func (c *Converter) UserXMLToJSON(user models.XMLUser) models.JSONUser {
 	return models.JSONUser{
-		ID:       user.ID,
-		FullName: user.Name,
-		Email:    user.Email,
-		AgeGroup: c.GetAgeGroup(user.Age),
+		ID:           user.ID,
+		FullName:     user.Name,
+		Email:        user.Email,
+		AgeGroup:     c.GetAgeGroup(user.Age),
+		AgeGroupCode: c.GetAgeGroupCode(user.Age),
+		Extra:        c.passthroughFields(user, extraToJSON(user.Extra)),
 	}
 }
 
+// extraToJSON переносит дополнительные поля XML в JSON
+func extraToJSON(extra map[string]string) map[string]any {
+	if len(extra) == 0 {
+		return nil
+	}
+	result := make(map[string]any, len(extra))
+	for key, value := range extra {
+		result[key] = value
+	}
+	return result
+}
+
 // UsersXMLToJSON асинхронно конвертирует срез пользователей XML в JSON.
 func (c *Converter) UsersXMLToJSON(users *models.XMLUsers) ([]models.JSONUser, error) {
 	if users == nil || len(users.Users) == 0 {
 		return nil, models.ErrNoUsers
 	}
 
-	// Конвертируем XML в JSON асинхронно
-	workerCount := runtime.NumCPU()
-	if len(users.Users) < workerCount {
-		workerCount = len(users.Users)
+	i := 0
+	next := func() (models.XMLUser, error) {
+		if i >= len(users.Users) {
+			return models.XMLUser{}, io.EOF
+		}
+		user := users.Users[i]
+		i++
+		return user, nil
+	}
+
+	finalUsers, _, err := c.collect(numbered(next))
+	return finalUsers, err
+}
+
+// UsersXMLStreamToJSON потоково читает пользователей из r и асинхронно конвертирует их в JSON.
+// Документ не загружается в память целиком: записи передаются в пул воркеров по мере чтения.
+func (c *Converter) UsersXMLStreamToJSON(r io.Reader) ([]models.JSONUser, error) {
+	finalUsers, total, err := c.collect(numbered(c.NewUserDecoder(r).Next))
+	if errors.Is(err, models.ErrInvalidXML) {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, models.ErrEmptyUsers
+	}
+	if finalUsers == nil {
+		finalUsers = []models.JSONUser{}
+	}
+
+	return finalUsers, err
+}
+
+// collect конвертирует все записи из next и применяет к пакету политику обработки дубликатов.
+// Ошибки валидации и описания дубликатов объединяются через errors.Join.
+func (c *Converter) collect(next recordSource) ([]models.JSONUser, int, error) {
+	if c.duplicates == DuplicateMerge {
+		return c.collectMerged(next)
+	}
+
+	var converted []indexedUser
+	total, err := c.convert(next, func(index int, user models.JSONUser) error {
+		converted = append(converted, indexedUser{index: index, user: user})
+		return nil
+	})
+	if errors.Is(err, models.ErrInvalidXML) {
+		return nil, total, err
 	}
-	jobs := make(chan job, len(users.Users))
-	results := make(chan result, len(users.Users))
+
+	finalUsers, duplicates := c.resolveDuplicates(converted)
+	return finalUsers, total, errors.Join(append([]error{err}, duplicates...)...)
+}
+
+// ConvertStream потоково читает пользователей из r и передает каждого валидного
+// пользователя в emit сразу после конвертации, сохраняя исходный порядок.
+// Ничего не накапливает, поэтому подходит для передачи данных дальше по конвейеру.
+// Повторные ID пропускаются (политика keep-first), другие политики дубликатов недоступны.
+// Число записей ограничивается Limits.MaxStreamUsers, а не MaxUsers.
+// Если emit возвращает ошибку, чтение прекращается и эта ошибка возвращается.
+func (c *Converter) ConvertStream(r io.Reader, emit func(models.JSONUser) error) (int, error) {
+	if c.duplicates != DuplicateKeepFirst {
+		return 0, fmt.Errorf("%w: %s", models.ErrDuplicatePolicyNotStreamable, c.duplicates)
+	}
+
+	decoder := c.NewUserDecoder(r)
+	decoder.maxUsers = c.limits.MaxStreamUsers
+
+	tracker := newDuplicateTracker()
+	total, err := c.convert(numbered(decoder.Next), func(index int, user models.JSONUser) error {
+		if !tracker.keep(index, user.ID) {
+			return nil
+		}
+		return emit(user)
+	})
+	return total, errors.Join(append([]error{err}, tracker.duplicates()...)...)
+}
+
+// recordSource возвращает очередную запись документа вместе с ее номером; io.EOF - записи кончились
+type recordSource func() (int, models.XMLUser, error)
+
+// numbered нумерует записи из next по порядку, начиная с 0
+func numbered(next func() (models.XMLUser, error)) recordSource {
+	index := 0
+	return func() (int, models.XMLUser, error) {
+		user, err := next()
+		if err != nil {
+			return 0, user, err
+		}
+		index++
+		return index - 1, user, nil
+	}
+}
+
+// convert прогоняет пользователей, получаемых из next, через пул воркеров
+// и передает валидных пользователей в emit в исходном порядке вместе с номером записи.
+// Каналы ограничены по размеру, поэтому одновременно в памяти находится
+// лишь небольшое окно записей. Возвращает число прочитанных записей.
+// Ошибка чтения прерывает обработку, ошибки валидации объединяются через errors.Join.
+func (c *Converter) convert(next recordSource, emit func(int, models.JSONUser) error) (int, error) {
+	workerCount := runtime.NumCPU()
+	jobs := make(chan job, workerCount)
+	results := make(chan result, workerCount)
+	done := make(chan struct{})
 
 	var wg sync.WaitGroup
 
@@ -53,60 +166,168 @@ func (c *Converter) UsersXMLToJSON(users *models.XMLUsers) ([]models.JSONUser, e
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				validatedUser, err := validateUser(job.user)
+				validatedUser, err := c.validateUser(job.user)
 				if err != nil {
-					results <- result{index: job.index, err: fmt.Errorf("user с ID #%d: %w", job.index, err)}
+					validationErr := c.validationError(job.index, validatedUser.ID, err)
+					validationErr.Position = validatedUser.Position
+					results <- result{seq: job.seq, index: job.index, err: validationErr}
 					continue
 				}
 
-				jsonUser := models.JSONUser{
-					ID:       validatedUser.ID,
-					FullName: validatedUser.Name,
-					Email:    validatedUser.Email,
-					AgeGroup: c.GetAgeGroup(validatedUser.Age),
+				jsonUser := c.UserXMLToJSON(validatedUser)
+				jsonUser.Index = job.index
+				results <- result{
+					seq:      job.seq,
+					index:    job.index,
+					jsonUser: jsonUser,
+					warning:  c.nameWarning(job.index, validatedUser),
 				}
-				results <- result{index: job.index, jsonUser: jsonUser}
 			}
 		}()
 	}
 
-	for i, user := range users.Users {
-		jobs <- job{index: i, user: user}
-	}
-	close(jobs)
+	// Читаем записи в отдельной горутине, чтобы параллельно собирать результаты
+	var (
+		total   int
+		readErr error
+	)
+	go func() {
+		defer close(jobs)
+		for {
+			index, user, err := next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
 
-	wg.Wait()
-	close(results)
+			select {
+			case jobs <- job{seq: total, index: index, user: user}:
+				total++
+			case <-done:
+				return
+			}
+		}
+	}()
 
-	jsonUsers := make([]models.JSONUser, len(users.Users))
-	validationErrors := make([]error, 0, len(users.Users))
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Результаты приходят не по порядку, поэтому буферизуем их до своей очереди
+	pending := make(map[int]result, workerCount)
+	nextSeq := 0
+	validationErrors := make([]error, 0)
+	var emitErr error
 
 	for res := range results {
-		if res.err != nil {
-			validationErrors = append(validationErrors, res.err)
-			continue
-		}
-		jsonUsers[res.index] = res.jsonUser
-	}
+		pending[res.seq] = res
+		for {
+			res, ok := pending[nextSeq]
+			if !ok {
+				break
+			}
+			delete(pending, nextSeq)
+			nextSeq++
 
-	// Отсеиваем нулевые структуры, которые остались на месте пользователей с ошибками
-	finalUsers := make([]models.JSONUser, 0, len(users.Users)-len(validationErrors))
-	for _, u := range jsonUsers {
-		if u.ID != "" {
-			finalUsers = append(finalUsers, u)
+			if res.err != nil {
+				validationErrors = append(validationErrors, res.err)
+				continue
+			}
+			// Предупреждения не отбрасывают запись и возвращаются вместе с ошибками валидации
+			if res.warning != nil {
+				validationErrors = append(validationErrors, res.warning)
+			}
+			if emitErr == nil {
+				if emitErr = emit(res.index, res.jsonUser); emitErr != nil {
+					close(done)
+				}
+			}
 		}
 	}
 
+	if readErr != nil {
+		return total, readErr
+	}
+	if emitErr != nil {
+		return total, emitErr
+	}
 	if len(validationErrors) > 0 {
-		return finalUsers, errors.Join(validationErrors...)
+		return total, errors.Join(validationErrors...)
+	}
+
+	return total, nil
+}
+
+// ValidationReport разворачивает ошибки валидации, объединенные через errors.Join,
+// в построчный отчет по записям в порядке документа
+func ValidationReport(err error) []models.ValidationError {
+	var report []models.ValidationError
+	for _, validationErr := range collectErrors[*models.ValidationError](err, nil) {
+		report = append(report, *validationErr)
 	}
+	return report
+}
 
-	return finalUsers, nil
+// DuplicateReport возвращает описания повторяющихся ID из ошибки конвертера
+func DuplicateReport(err error) []models.DuplicateError {
+	var report []models.DuplicateError
+	for _, duplicate := range collectErrors[*models.DuplicateError](err, nil) {
+		report = append(report, *duplicate)
+	}
+	return report
+}
+
+// WarningReport возвращает предупреждения о принятых записях из ошибки конвертера
+func WarningReport(err error) []models.Warning {
+	var report []models.Warning
+	for _, warning := range collectErrors[*models.Warning](err, nil) {
+		report = append(report, *warning)
+	}
+	return report
 }
 
-// validateUser - функция для валидации пользователей
-func validateUser(user models.XMLUser) (models.XMLUser, error) {
+// collectErrors собирает ошибки типа T из дерева ошибок, объединенных через errors.Join
+func collectErrors[T error](err error, collected []T) []T {
+	if err == nil {
+		return collected
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			collected = collectErrors(err, collected)
+		}
+		return collected
+	}
+
+	var target T
+	if errors.As(err, &target) {
+		collected = append(collected, target)
+	}
+	return collected
+}
+
+// validateUser - функция для валидации пользователей.
+// Если указана дата рождения, возраст вычисляется по ней, иначе разбирается из текста <age>.
+func (c *Converter) validateUser(user models.XMLUser) (models.XMLUser, error) {
 	user = cleanFromSpaces(user)
+	user.Name = c.normalizeName(user.Name)
+
+	if user.BirthDate != "" {
+		age, err := c.ageFromBirthDate(user.BirthDate)
+		if err != nil {
+			return user, err
+		}
+		user.Age = age
+	} else if user.AgeText != "" {
+		age, err := strconv.Atoi(user.AgeText)
+		if err != nil {
+			return user, fmt.Errorf("%w: %q", models.ErrInvalidAge, user.AgeText)
+		}
+		user.Age = age
+	}
 
 	if user.ID == "" {
 		return user, models.ErrEmptyID
@@ -117,6 +338,11 @@ func validateUser(user models.XMLUser) (models.XMLUser, error) {
 	if user.Email == "" {
 		return user, models.ErrEmptyEmail
 	}
+	email, err := c.normalizeEmail(user.Email)
+	if err != nil {
+		return user, err
+	}
+	user.Email = email
 	if user.Age <= 0 || user.Age > 110 {
 		return user, models.ErrInvalidAge
 	}
@@ -128,5 +354,7 @@ func cleanFromSpaces(user models.XMLUser) models.XMLUser {
 	user.ID = strings.TrimSpace(user.ID)
 	user.Name = strings.TrimSpace(user.Name)
 	user.Email = strings.TrimSpace(user.Email)
+	user.BirthDate = strings.TrimSpace(user.BirthDate)
+	user.AgeText = strings.TrimSpace(user.AgeText)
 	return user
 }
@@ -1,8 +1,10 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/NarthurN/GoXML_JSON/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -416,6 +418,81 @@ func TestConverter_UsersXMLToJSON_MixedValidInvalid(t *testing.T) {
 	assert.True(t, ids["1"])
 	assert.True(t, ids["3"])
 	assert.False(t, ids[""]) // Невалидный пользователь не должен быть в результате
+
+	// Номер записи в документе сохраняется, несмотря на пропущенную запись
+	assert.Equal(t, 0, result[0].Index)
+	assert.Equal(t, 2, result[1].Index)
+}
+
+func TestValidationReport(t *testing.T) {
+	converter := NewConverter(WithAsOfDate(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	users := &models.XMLUsers{
+		Users: []models.XMLUser{
+			{ID: "1", Name: "Иван Иванов", Email: "ivan@example.com", Age: 30},
+			{ID: "2", Name: "Мария Петрова", Email: " ", Age: 25},
+			{ID: "3", Name: "Петр Сидоров", Email: "petr@example.com", Age: 150},
+			{ID: "", Name: "Без ID", Email: "noid@example.com", Age: 40},
+			{ID: "5", Name: "Анна", Email: "anna@example.com", BirthDate: "2030-01-01"},
+		},
+	}
+
+	result, err := converter.UsersXMLToJSON(users)
+	require.Error(t, err)
+	assert.Len(t, result, 1)
+
+	report := ValidationReport(err)
+	require.Len(t, report, 4)
+	assert.Equal(t, 1, report[0].Index)
+	assert.Equal(t, "2", report[0].UserID)
+	assert.Equal(t, "email", report[0].Field)
+	assert.Equal(t, models.CodeEmptyEmail, report[0].Code)
+	assert.Equal(t, "пустой email", report[0].Message)
+	assert.Equal(t, []string{models.CodeInvalidAge, models.CodeEmptyID, models.CodeFutureBirthDate},
+		[]string{report[1].Code, report[2].Code, report[3].Code})
+	assert.Equal(t, []int{2, 3, 4}, []int{report[1].Index, report[2].Index, report[3].Index})
+	assert.Equal(t, "3", report[1].UserID)
+	assert.Equal(t, "birthdate", report[3].Field)
+
+	var validationErr *models.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, models.CodeEmptyEmail, validationErr.Code)
+	assert.ErrorIs(t, err, models.ErrEmptyEmail)
+	assert.NotErrorIs(t, validationErr, models.ErrEmptyName)
+	assert.Equal(t, `user #1 (id "2"): EMPTY_EMAIL: пустой email`, validationErr.Error())
+
+	assert.Nil(t, ValidationReport(nil))
+}
+
+func TestValidationReport_Language(t *testing.T) {
+	users := &models.XMLUsers{
+		Users: []models.XMLUser{
+			{ID: "1", Name: "Иван Иванов", Email: "", Age: 30},
+			{ID: "2", Name: "Мария Петрова", Email: "maria@example.com", BirthDate: "вчера"},
+		},
+	}
+
+	tests := []struct {
+		lang     string
+		messages []string
+	}{
+		{lang: "ru", messages: []string{"пустой email", "некорректная дата рождения"}},
+		{lang: "en", messages: []string{"empty email", "invalid birth date"}},
+		{lang: "kk", messages: []string{"email бос", "туған күні дұрыс емес"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			_, err := NewConverter().ForLanguage(tt.lang).UsersXMLToJSON(users)
+
+			report := ValidationReport(err)
+			require.Len(t, report, 2)
+			assert.Equal(t, tt.messages, []string{report[0].Message, report[1].Message})
+			assert.Equal(t, models.CodeInvalidBirthDate, report[1].Code)
+			// Подробности исходной ошибки сохраняются для логов
+			assert.Contains(t, err.Error(), `"вчера"`)
+		})
+	}
 }
 
 func TestConverter_UsersXMLToJSON_Concurrency(t *testing.T) {
@@ -450,6 +527,41 @@ func TestConverter_UsersXMLToJSON_Concurrency(t *testing.T) {
 	}
 }
 
+func TestConverter_ConvertStream(t *testing.T) {
+	converter := NewConverter()
+
+	t.Run("пользователи передаются по порядку", func(t *testing.T) {
+		var ids []string
+		total, err := converter.ConvertStream(generateUsersXML(1000), func(user models.JSONUser) error {
+			ids = append(ids, user.ID)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1000, total)
+		require.Len(t, ids, 1000)
+		for i, id := range ids {
+			assert.Equal(t, fmt.Sprintf("%d", i+1), id)
+		}
+	})
+
+	t.Run("ошибка emit прерывает чтение", func(t *testing.T) {
+		errStop := errors.New("получатель недоступен")
+		emitted := 0
+		total, err := converter.ConvertStream(generateUsersXML(100000), func(user models.JSONUser) error {
+			emitted++
+			if emitted == 10 {
+				return errStop
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, err, errStop)
+		assert.Equal(t, 10, emitted)
+		assert.Less(t, total, 100000)
+	})
+}
+
 // Benchmark тест для проверки производительности
 func BenchmarkConverter_UsersXMLToJSON(b *testing.B) {
 	converter := NewConverter()
@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage - язык названий возрастных групп, используемый по умолчанию.
+// Названия на этом языке задаются прямо в диапазонах (поле label).
+const DefaultLanguage = "ru"
+
+// LabelCatalog - переводы названий возрастных групп: язык -> код группы -> название
+type LabelCatalog map[string]map[string]string
+
+// DefaultLabelCatalog возвращает переводы для групп по умолчанию
+func DefaultLabelCatalog() LabelCatalog {
+	return LabelCatalog{
+		"en": {
+			AgeGroupCodeYang:   "under 25",
+			AgeGroupCodeMiddle: "25 to 35",
+			AgeGroupCodeOld:    "over 35",
+		},
+		"kk": {
+			AgeGroupCodeYang:   "25 жасқа дейін",
+			AgeGroupCodeMiddle: "25-тен 35-ке дейін",
+			AgeGroupCodeOld:    "35-тен жоғары",
+		},
+	}
+}
+
+// Label возвращает название группы на языке lang.
+// Если перевода нет, возвращается русское название.
+func (c LabelCatalog) Label(lang string, ageRange AgeRange) string {
+	if label, ok := c[lang][ageRange.Code]; ok {
+		return label
+	}
+	return ageRange.Label
+}
+
+// Supports сообщает, есть ли названия на языке lang
+func (c LabelCatalog) Supports(lang string) bool {
+	if lang == DefaultLanguage {
+		return true
+	}
+	_, ok := c[lang]
+	return ok
+}
+
+// Languages возвращает все поддерживаемые языки, начиная с языка по умолчанию
+func (c LabelCatalog) Languages() []string {
+	languages := make([]string, 0, len(c)+1)
+	for lang := range c {
+		if lang != DefaultLanguage {
+			languages = append(languages, lang)
+		}
+	}
+	sort.Strings(languages)
+	return append([]string{DefaultLanguage}, languages...)
+}
+
+// Language возвращает язык названий возрастных групп
+func (c *Converter) Language() string {
+	return c.language
+}
+
+// Languages возвращает языки, на которых доступны названия возрастных групп
+func (c *Converter) Languages() []string {
+	return c.labels.Languages()
+}
+
+// ForLanguage возвращает копию конвертера, выдающую названия групп на языке lang.
+// Неподдерживаемый язык заменяется языком по умолчанию.
+func (c *Converter) ForLanguage(lang string) *Converter {
+	if !c.labels.Supports(lang) {
+		lang = DefaultLanguage
+	}
+
+	scoped := *c
+	scoped.language = lang
+	return &scoped
+}
+
+// MatchLanguage выбирает поддерживаемый язык по значению заголовка Accept-Language
+// (например "en-US,en;q=0.9,ru;q=0.8"). Учитываются веса q и основной подтег языка.
+// Если ни один язык не подходит, возвращает пустую строку.
+func (c *Converter) MatchLanguage(acceptLanguage string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if quality <= bestQuality {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(tag, "-")
+		if c.labels.Supports(primary) {
+			best, bestQuality = primary, quality
+		}
+	}
+	return best
+}
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter_ForLanguage(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		lang     string
+		age      int
+		expected string
+	}{
+		{"ru", 20, AgeGroupYang},
+		{"en", 20, "under 25"},
+		{"en", 30, "25 to 35"},
+		{"en", 40, "over 35"},
+		{"kk", 30, "25-тен 35-ке дейін"},
+		{"de", 30, AgeGroupMiddle}, // Неподдерживаемый язык - русские названия
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			localized := converter.ForLanguage(tt.lang)
+			assert.Equal(t, tt.expected, localized.GetAgeGroup(tt.age))
+		})
+	}
+
+	// Исходный конвертер остается на русском
+	assert.Equal(t, DefaultLanguage, converter.Language())
+	assert.Equal(t, AgeGroupMiddle, converter.GetAgeGroup(30))
+}
+
+func TestConverter_GetAgeGroupCode(t *testing.T) {
+	converter := NewConverter().ForLanguage("en")
+
+	assert.Equal(t, AgeGroupCodeYang, converter.GetAgeGroupCode(24))
+	assert.Equal(t, AgeGroupCodeMiddle, converter.GetAgeGroupCode(25))
+	assert.Equal(t, AgeGroupCodeMiddle, converter.GetAgeGroupCode(35))
+	assert.Equal(t, AgeGroupCodeOld, converter.GetAgeGroupCode(36))
+
+	jsonUser := converter.UserXMLToJSON(models.XMLUser{ID: "1", Name: "John", Email: "john@example.com", Age: 30})
+	assert.Equal(t, "25 to 35", jsonUser.AgeGroup)
+	assert.Equal(t, AgeGroupCodeMiddle, jsonUser.AgeGroupCode)
+}
+
+func TestConverter_WithLanguage(t *testing.T) {
+	assert.Equal(t, "en", NewConverter(WithLanguage("en")).Language())
+	assert.Equal(t, DefaultLanguage, NewConverter(WithLanguage("fr")).Language())
+}
+
+func TestConverter_MatchLanguage(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"en", "en"},
+		{"en-US,en;q=0.9", "en"},
+		{"de-DE,de;q=0.9,kk;q=0.5,ru;q=0.3", "kk"},
+		{"ru;q=0.5, en;q=0.8", "en"},
+		{"EN-gb", "en"},
+		{"fr, de", ""},
+		{"*", ""},
+		{"en;q=0, ru;q=0.1", "ru"},
+		{"en;q=abc, kk", "kk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, converter.MatchLanguage(tt.header))
+		})
+	}
+}
+
+func TestLabelCatalog_Languages(t *testing.T) {
+	languages := DefaultLabelCatalog().Languages()
+
+	require.NotEmpty(t, languages)
+	assert.Equal(t, []string{"ru", "en", "kk"}, languages)
+}
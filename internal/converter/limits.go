@@ -0,0 +1,148 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Limits - ограничения на входной документ; нулевое значение снимает ограничение.
+// DOCTYPE и объявления сущностей отклоняются всегда.
+//
+// Длины текста и атрибутов проверяются по готовым токенам: encoding/xml к этому моменту
+// уже прочитал токен в память целиком. Ограничения не дают передать длинное значение дальше,
+// но от буферизации одного большого токена внутри декодера защищает только лимит размера тела запроса.
+type Limits struct {
+	MaxDepth       int // Максимальная вложенность элементов
+	MaxUsers       int // Максимальное число записей пользователей
+	MaxStreamUsers int // Максимальное число записей в ConvertStream, где память не растет с числом записей
+	MaxTextLength  int // Максимальная длина текста элемента, байт
+	MaxAttrLength  int // Максимальная длина значения атрибута, байт
+}
+
+// DefaultLimits возвращает ограничения по умолчанию
+func DefaultLimits() Limits {
+	return Limits{
+		MaxDepth:      64,
+		MaxTextLength: 1 << 20,
+		MaxAttrLength: 64 << 10,
+	}
+}
+
+// WithLimits задает ограничения на входной документ
+func WithLimits(limits Limits) Option {
+	return func(c *Converter) {
+		c.limits = limits
+	}
+}
+
+// Limits возвращает ограничения на входной документ
+func (c *Converter) Limits() Limits {
+	return c.limits
+}
+
+// xmlDecoder - xml.Decoder, читающий документ через tokenLimiter.
+// Позиция берется из исходного декодера: внешний не видит байтов документа.
+type xmlDecoder struct {
+	*xml.Decoder
+	source *xml.Decoder
+	reader *positionReader
+}
+
+// InputPos возвращает строку и колонку (в байтах) текущей позиции в документе
+func (d *xmlDecoder) InputPos() (line, column int) {
+	return d.source.InputPos()
+}
+
+// startRecord отмечает начало записи, открывающий тег которой начался в line:column
+func (d *xmlDecoder) startRecord(line, column int) {
+	d.reader.startRecord(line, column)
+}
+
+// recordPosition возвращает место текущей записи в документе
+func (d *xmlDecoder) recordPosition() models.Position {
+	return d.reader.recordPosition()
+}
+
+// newLimitedDecoder оборачивает source проверкой ограничений.
+// Пространства имен разрешает внешний декодер, поэтому токены читаются через RawToken.
+func newLimitedDecoder(source *xml.Decoder, reader *positionReader, limits Limits) *xmlDecoder {
+	return &xmlDecoder{
+		Decoder: xml.NewTokenDecoder(&tokenLimiter{source: source, reader: reader, limits: limits}),
+		source:  source,
+		reader:  reader,
+	}
+}
+
+// tokenLimiter проверяет ограничения на каждом токене документа
+// и дополняет ошибки разбора местом в документе.
+// Токен проверяется после того, как source прочитал его целиком.
+type tokenLimiter struct {
+	source *xml.Decoder
+	reader *positionReader
+	limits Limits
+	open   []xml.Name // Открытые элементы: длина стека ограничена MaxDepth
+}
+
+func (l *tokenLimiter) Token() (xml.Token, error) {
+	token, err := l.source.RawToken()
+	switch {
+	case err == io.EOF && len(l.open) > 0:
+		return nil, l.syntaxError("unexpected EOF")
+	case err == io.EOF:
+		return nil, err
+	case err != nil:
+		return nil, l.positionError(err)
+	}
+
+	switch t := token.(type) {
+	case xml.StartElement:
+		if l.limits.MaxDepth > 0 && len(l.open) >= l.limits.MaxDepth {
+			return nil, l.limitError("вложенность элементов больше %d", l.limits.MaxDepth)
+		}
+		for _, attr := range t.Attr {
+			if l.limits.MaxAttrLength > 0 && len(attr.Value) > l.limits.MaxAttrLength {
+				return nil, l.limitError("значение атрибута %s длиннее %d байт", attr.Name.Local, l.limits.MaxAttrLength)
+			}
+		}
+		l.open = append(l.open, t.Name)
+	case xml.EndElement:
+		// Проверяем парность тегов здесь: внешний декодер не знает номера строки
+		if len(l.open) == 0 {
+			return nil, l.syntaxError("unexpected end element </" + t.Name.Local + ">")
+		}
+		start := l.open[len(l.open)-1]
+		if start != t.Name {
+			return nil, l.syntaxError("element <" + start.Local + "> closed by </" + t.Name.Local + ">")
+		}
+		l.open = l.open[:len(l.open)-1]
+	case xml.CharData:
+		if l.limits.MaxTextLength > 0 && len(t) > l.limits.MaxTextLength {
+			return nil, l.limitError("текст элемента длиннее %d байт", l.limits.MaxTextLength)
+		}
+	case xml.Directive:
+		// Сущности не раскрываются, но DTD может быть сколь угодно большим
+		if bytes.Contains(t, []byte("DOCTYPE")) || bytes.Contains(t, []byte("ENTITY")) {
+			return nil, l.positionError(models.ErrDoctypeForbidden)
+		}
+	}
+	return token, nil
+}
+
+// positionError дополняет ошибку местом в документе и фрагментом строки
+func (l *tokenLimiter) positionError(err error) error {
+	line, column := l.source.InputPos()
+	return &models.PositionError{Position: l.reader.errorPosition(line, column), Err: err}
+}
+
+func (l *tokenLimiter) syntaxError(msg string) error {
+	line, _ := l.source.InputPos()
+	return l.positionError(&xml.SyntaxError{Msg: msg, Line: line})
+}
+
+func (l *tokenLimiter) limitError(format string, args ...any) error {
+	return l.positionError(fmt.Errorf("%w: %s", models.ErrXMLLimitExceeded, fmt.Sprintf(format, args...)))
+}
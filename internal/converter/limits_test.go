@@ -0,0 +1,159 @@
+package converter
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter_Limits(t *testing.T) {
+	limits := Limits{MaxDepth: 4, MaxUsers: 2, MaxTextLength: 24, MaxAttrLength: 8}
+
+	tests := []struct {
+		name    string
+		xml     string
+		err     error
+		message string
+	}{
+		{
+			name: "billion laughs",
+			xml: `<?xml version="1.0"?>
+<!DOCTYPE users [
+  <!ENTITY lol "lol">
+  <!ENTITY lol2 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+]>
+<users><user id="1"><name>&lol2;</name></user></users>`,
+			err:     models.ErrDoctypeForbidden,
+			message: "строка 5",
+		},
+		{
+			name:    "внешняя сущность",
+			xml:     `<!DOCTYPE users SYSTEM "file:///etc/passwd"><users/>`,
+			err:     models.ErrDoctypeForbidden,
+			message: "строка 1",
+		},
+		{
+			name:    "глубокая вложенность",
+			xml:     `<users><user id="1"><name><a><b>Иван</b></a></name></user></users>`,
+			err:     models.ErrXMLLimitExceeded,
+			message: "вложенность элементов больше 4",
+		},
+		{
+			name:    "длинный текст",
+			xml:     `<users><user id="1"><name>` + strings.Repeat("И", 13) + `</name></user></users>`,
+			err:     models.ErrXMLLimitExceeded,
+			message: "текст элемента длиннее 24 байт",
+		},
+		{
+			name:    "длинный атрибут",
+			xml:     `<users><user id="123456789"><name>Иван</name></user></users>`,
+			err:     models.ErrXMLLimitExceeded,
+			message: "значение атрибута id длиннее 8 байт",
+		},
+		{
+			name: "слишком много пользователей",
+			xml: `<users>
+  <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+  <user id="2"><name>Мария</name><email>maria@example.com</email><age>25</age></user>
+  <user id="3"><name>Петр</name><email>petr@example.com</email><age>40</age></user>
+</users>`,
+			err:     models.ErrTooManyUsers,
+			message: "больше 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := NewConverter(WithLimits(limits))
+
+			_, err := converter.UsersXMLStreamToJSON(strings.NewReader(tt.xml))
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, models.ErrInvalidXML)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestConverter_Limits_WithinLimits(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <users>
+      <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+    </users>
+  </soap:Body>
+</soap:Envelope>`
+
+	converter := NewConverter(WithLimits(Limits{MaxDepth: 5, MaxUsers: 1, MaxTextLength: 32, MaxAttrLength: 64}))
+	result, err := converter.UsersXMLStreamToJSON(strings.NewReader(xml))
+
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "Иван", result[0].FullName)
+	assert.Equal(t, DefaultLimits(), NewConverter().Limits())
+}
+
+func TestConverter_Limits_Stream(t *testing.T) {
+	emit := func(models.JSONUser) error { return nil }
+
+	// В конвейерном режиме MaxUsers не действует: документ не держится в памяти
+	converter := NewConverter(WithLimits(Limits{MaxUsers: 2}))
+	total, err := converter.ConvertStream(generateUsersXML(10), emit)
+	require.NoError(t, err)
+	assert.Equal(t, 10, total)
+
+	converter = NewConverter(WithLimits(Limits{MaxUsers: 100, MaxStreamUsers: 3}))
+	_, err = converter.ConvertStream(generateUsersXML(10), emit)
+	assert.ErrorIs(t, err, models.ErrTooManyUsers)
+}
+
+func TestConverter_Limits_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		line int
+		msg  string
+	}{
+		{
+			name: "непарный тег",
+			xml:  "<users>\n  <user id=\"1\">\n    <name>Иван</email>\n  </user>\n</users>",
+			line: 3,
+			msg:  "element <name> closed by </email>",
+		},
+		{
+			name: "обрыв документа",
+			xml:  "<users>\n  <user id=\"1\">\n    <name>Иван</name>\n",
+			line: 4,
+			msg:  "unexpected EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConverter().UsersXMLStreamToJSON(strings.NewReader(tt.xml))
+
+			var syntaxErr *xml.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.line, syntaxErr.Line)
+			assert.Equal(t, tt.msg, syntaxErr.Msg)
+		})
+	}
+}
+
+func TestSchema_Validate_Doctype(t *testing.T) {
+	schema, err := ParseSchema([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="users" type="xs:string"/>
+</xs:schema>`))
+	require.NoError(t, err)
+
+	err = schema.Validate(strings.NewReader(`<!DOCTYPE users [<!ENTITY x "x">]><users>&x;</users>`))
+
+	assert.ErrorIs(t, err, models.ErrInvalidXML)
+	assert.ErrorIs(t, err, models.ErrDoctypeForbidden)
+}
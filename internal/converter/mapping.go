@@ -0,0 +1,219 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Ключи JSON, которые заполняют стандартные поля пользователя.
+// Остальные ключи маппинга попадают в объект как дополнительные поля.
+const (
+	TargetID       = "id"
+	TargetFullName = "full_name"
+	TargetEmail    = "email"
+	TargetAgeGroup = "age_group"
+)
+
+// Преобразования значений в маппинге
+const (
+	TransformTrim     = "trim"      // Удаляет пробелы по краям
+	TransformLower    = "lower"     // Приводит к нижнему регистру
+	TransformConstant = "constant"  // Заменяет значение на FieldMapping.Value
+	TransformAgeGroup = "age_group" // Значение - возраст или дата рождения, из которых вычисляется группа
+)
+
+// FieldMapping - правило заполнения одного ключа JSON
+type FieldMapping struct {
+	Target     string   `json:"target"`               // Ключ в JSON
+	Source     string   `json:"source,omitempty"`     // Путь в XML записи: "name", "@id", "contact/email"
+	Default    string   `json:"default,omitempty"`    // Значение, если источник отсутствует или пуст
+	Value      string   `json:"value,omitempty"`      // Значение для преобразования constant
+	Transforms []string `json:"transforms,omitempty"` // Преобразования в порядке применения
+}
+
+// Mapping - декларативное описание формата XML партнера
+type Mapping struct {
+	Name   string         `json:"-"`      // Название маппинга (имя файла)
+	Root   string         `json:"root"`   // Корневой элемент, по умолчанию users
+	Record string         `json:"record"` // Элемент записи, по умолчанию user
+	Fields []FieldMapping `json:"fields"`
+
+	Namespaces []string `json:"namespaces,omitempty"` // Разрешенные пространства имен партнера
+}
+
+// LoadMapping загружает маппинг из JSON файла; имя маппинга - имя файла без расширения
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping Mapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", models.ErrInvalidMapping, path, err)
+	}
+	mapping.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if err := mapping.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &mapping, nil
+}
+
+// LoadMappings загружает все маппинги (*.json) из каталога
+func LoadMappings(dir string) (map[string]*Mapping, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make(map[string]*Mapping, len(paths))
+	for _, path := range paths {
+		mapping, err := LoadMapping(path)
+		if err != nil {
+			return nil, err
+		}
+		mappings[mapping.Name] = mapping
+	}
+	return mappings, nil
+}
+
+// compile проверяет маппинг и заполняет значения по умолчанию
+func (m *Mapping) compile() error {
+	if m.Root == "" {
+		m.Root = "users"
+	}
+	if m.Record == "" {
+		m.Record = "user"
+	}
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("%w: нет ни одного поля", models.ErrInvalidMapping)
+	}
+
+	targets := make(map[string]struct{}, len(m.Fields))
+	for i, field := range m.Fields {
+		if field.Target == "" {
+			return fmt.Errorf("%w: поле #%d без ключа", models.ErrInvalidMapping, i)
+		}
+		if _, ok := targets[field.Target]; ok {
+			return fmt.Errorf("%w: ключ %q повторяется", models.ErrInvalidMapping, field.Target)
+		}
+		targets[field.Target] = struct{}{}
+
+		constant, ageGroup := false, false
+		for _, transform := range field.Transforms {
+			switch transform {
+			case TransformTrim, TransformLower:
+			case TransformConstant:
+				constant = true
+			case TransformAgeGroup:
+				ageGroup = true
+			default:
+				return fmt.Errorf("%w: %q: неизвестное преобразование %q", models.ErrInvalidMapping, field.Target, transform)
+			}
+		}
+
+		if field.Source == "" && field.Default == "" && !constant {
+			return fmt.Errorf("%w: %q: не задан источник", models.ErrInvalidMapping, field.Target)
+		}
+		if ageGroup != (field.Target == TargetAgeGroup) {
+			return fmt.Errorf("%w: преобразование %s применимо только к ключу %s", models.ErrInvalidMapping, TransformAgeGroup, TargetAgeGroup)
+		}
+		// Одинаковый ID у всех записей превратил бы документ в повторы одной записи
+		if field.Target == TargetID && (constant || field.Source == "") {
+			return fmt.Errorf("%w: ключ %s должен браться из записи", models.ErrInvalidMapping, TargetID)
+		}
+	}
+
+	// Стандартные поля проходят валидацию: без любого из них отклонялась бы каждая запись
+	for _, target := range []string{TargetID, TargetFullName, TargetEmail, TargetAgeGroup} {
+		if _, ok := targets[target]; !ok {
+			return fmt.Errorf("%w: нет ключа %s", models.ErrInvalidMapping, target)
+		}
+	}
+	return nil
+}
+
+// Apply строит пользователя из XML записи по правилам маппинга
+func (m *Mapping) Apply(record *models.XMLNode) models.XMLUser {
+	var user models.XMLUser
+	for _, field := range m.Fields {
+		value := field.value(record)
+
+		switch field.Target {
+		case TargetID:
+			user.ID = value
+		case TargetFullName:
+			user.Name = value
+		case TargetEmail:
+			user.Email = value
+		case TargetAgeGroup:
+			// Целое число - возраст, иначе значение считается датой рождения
+			if age, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				user.Age = age
+			} else {
+				user.BirthDate = value
+			}
+		default:
+			if user.Extra == nil {
+				user.Extra = make(map[string]string)
+			}
+			user.Extra[field.Target] = value
+		}
+	}
+	return user
+}
+
+// value вычисляет значение поля для записи
+func (f FieldMapping) value(record *models.XMLNode) string {
+	value, ok := "", false
+	if f.Source != "" {
+		value, ok = record.Find(f.Source)
+	}
+	if !ok || strings.TrimSpace(value) == "" {
+		value = f.Default
+	}
+
+	for _, transform := range f.Transforms {
+		switch transform {
+		case TransformTrim:
+			value = strings.TrimSpace(value)
+		case TransformLower:
+			value = strings.ToLower(value)
+		case TransformConstant:
+			value = f.Value
+		}
+	}
+	return value
+}
+
+// ForMapping возвращает копию конвертера, читающую XML по маппингу name.
+// Пустое имя означает стандартный формат <users><user id="..">...</user></users>.
+func (c *Converter) ForMapping(name string) (*Converter, error) {
+	scoped := *c
+	if name == "" {
+		scoped.mapping = nil
+		return &scoped, nil
+	}
+
+	mapping, ok := c.mappings[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", models.ErrUnknownMapping, name)
+	}
+	scoped.mapping = mapping
+	return &scoped, nil
+}
+
+// Mapping возвращает название используемого маппинга или пустую строку
+func (c *Converter) Mapping() string {
+	if c.mapping == nil {
+		return ""
+	}
+	return c.mapping.Name
+}
@@ -0,0 +1,189 @@
+package converter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const partnerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<employees>
+    <employee code=" E-1 ">
+        <person>
+            <full_name>Иван Иванов</full_name>
+            <birthdate>1990-04-12</birthdate>
+        </person>
+        <contact><email> IVAN@Example.com </email></contact>
+        <department>Бухгалтерия</department>
+    </employee>
+    <employee code="E-2">
+        <person>
+            <full_name>Мария Петрова</full_name>
+            <birthdate>22</birthdate>
+        </person>
+        <contact><email>maria@example.com</email></contact>
+    </employee>
+</employees>`
+
+func TestXMLNode_Find(t *testing.T) {
+	var node models.XMLNode
+	err := xml.Unmarshal([]byte(`<employee code="E-1">
+    <person><full_name> Иван Иванов </full_name></person>
+    <contact type="work"><email>ivan@example.com</email></contact>
+    <contact type="home"><email>home@example.com</email></contact>
+</employee>`), &node)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		expected string
+		found    bool
+	}{
+		{"@code", "E-1", true},
+		{"person/full_name", " Иван Иванов ", true},
+		{"contact/email", "ivan@example.com", true},
+		{"contact/@type", "work", true},
+		{"person/age", "", false},
+		{"@missing", "", false},
+		{"@code/person", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			value, found := node.Find(tt.path)
+
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestLoadMappings(t *testing.T) {
+	mappings, err := LoadMappings(filepath.Join("..", "..", "mappings"))
+
+	require.NoError(t, err)
+	require.Contains(t, mappings, "hr_partner")
+	assert.Equal(t, "employees", mappings["hr_partner"].Root)
+	assert.Equal(t, "employee", mappings["hr_partner"].Record)
+}
+
+// Правила стандартных полей для проверки маппингов
+const (
+	idField       = `{"target": "id", "source": "@id"}`
+	fullNameField = `{"target": "full_name", "source": "name"}`
+	emailField    = `{"target": "email", "source": "email"}`
+	ageGroupField = `{"target": "age_group", "source": "age", "transforms": ["age_group"]}`
+)
+
+func TestLoadMapping_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"некорректный JSON", `{"fields": [`},
+		{"нет полей", `{"fields": []}`},
+		{"поле без ключа", `{"fields": [{"source": "name"}]}`},
+		{"повторяющийся ключ", `{"fields": [{"target": "id", "source": "@id"}, {"target": "id", "source": "@code"}]}`},
+		{"неизвестное преобразование", `{"fields": [{"target": "id", "source": "@id", "transforms": ["reverse"]}]}`},
+		{"нет источника", `{"fields": [{"target": "id"}]}`},
+		{"age_group без преобразования", `{"fields": [{"target": "age_group", "source": "age"}]}`},
+		{"age_group для другого ключа", `{"fields": [{"target": "age", "source": "age", "transforms": ["age_group"]}]}`},
+		{"нет id", `{"fields": [` + fullNameField + `, ` + emailField + `, ` + ageGroupField + `]}`},
+		{"нет full_name", `{"fields": [` + idField + `, ` + emailField + `, ` + ageGroupField + `]}`},
+		{"нет email", `{"fields": [` + idField + `, ` + fullNameField + `, ` + ageGroupField + `]}`},
+		{"нет age_group", `{"fields": [` + idField + `, ` + fullNameField + `, ` + emailField + `]}`},
+		{"постоянный id", `{"fields": [{"target": "id", "transforms": ["constant"], "value": "1"}, ` + fullNameField + `, ` + emailField + `, ` + ageGroupField + `]}`},
+		{"id только по умолчанию", `{"fields": [{"target": "id", "default": "1"}, ` + fullNameField + `, ` + emailField + `, ` + ageGroupField + `]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "partner.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.data), 0644))
+
+			_, err := LoadMapping(path)
+
+			assert.ErrorIs(t, err, models.ErrInvalidMapping)
+		})
+	}
+}
+
+func TestLoadMapping_StandardFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partner.json")
+	data := `{"fields": [` + idField + `, ` + fullNameField + `, ` + emailField + `, ` + ageGroupField + `]}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	mapping, err := LoadMapping(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "partner", mapping.Name)
+	assert.Len(t, mapping.Fields, 4)
+}
+
+func TestConverter_ForMapping(t *testing.T) {
+	mappings, err := LoadMappings(filepath.Join("..", "..", "mappings"))
+	require.NoError(t, err)
+
+	converter := NewConverter(
+		WithMappings(mappings),
+		WithAsOfDate(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)),
+	)
+
+	_, err = converter.ForMapping("unknown")
+	assert.ErrorIs(t, err, models.ErrUnknownMapping)
+
+	partner, err := converter.ForMapping("hr_partner")
+	require.NoError(t, err)
+	assert.Equal(t, "hr_partner", partner.Mapping())
+	assert.Equal(t, "", converter.Mapping())
+
+	result, err := partner.UsersXMLStreamToJSON(strings.NewReader(partnerXML))
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+
+	assert.Equal(t, "E-1", result[0].ID)
+	assert.Equal(t, "Иван Иванов", result[0].FullName)
+	assert.Equal(t, "ivan@example.com", result[0].Email)
+	assert.Equal(t, AgeGroupMiddle, result[0].AgeGroup)
+	assert.Equal(t, map[string]any{"department": "Бухгалтерия", "source": "hr_partner"}, result[0].Extra)
+
+	// Целое число в источнике age_group трактуется как возраст, пустое поле - значение по умолчанию
+	assert.Equal(t, AgeGroupYang, result[1].AgeGroup)
+	assert.Equal(t, "не указан", result[1].Extra["department"])
+
+	data, err := json.Marshal(result[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "E-1",
+		"full_name": "Иван Иванов",
+		"email": "ivan@example.com",
+		"age_group": "от 25 до 35",
+		"age_group_code": "25_35",
+		"department": "Бухгалтерия",
+		"source": "hr_partner"
+	}`, string(data))
+
+	// Стандартный формат не подходит под маппинг
+	_, err = partner.ParseXML([]byte(`<users><user id="1"><name>Иван</name></user></users>`))
+	assert.ErrorIs(t, err, models.ErrInvalidXML)
+}
+
+func TestMapping_Apply_ExtraDoesNotOverrideStandardFields(t *testing.T) {
+	user := models.JSONUser{
+		ID:    "1",
+		Extra: map[string]any{"id": "подмена", "phone": "+7 700 000 00 00"},
+	}
+
+	data, err := json.Marshal(user)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": "1", "full_name": "", "email": "", "age_group": "", "phone": "+7 700 000 00 00"}`, string(data))
+}
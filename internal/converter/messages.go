@@ -0,0 +1,54 @@
+package converter
+
+import "github.com/NarthurN/GoXML_JSON/internal/models"
+
+// validationMessages - переводы сообщений об ошибках валидации и предупреждений по кодам.
+// Русские сообщения заданы в самих ошибках models.
+var validationMessages = map[string]map[string]string{
+	"en": {
+		models.CodeEmptyID:          "empty id",
+		models.CodeEmptyName:        "empty name",
+		models.CodeEmptyEmail:       "empty email",
+		models.CodeInvalidEmail:     "invalid email",
+		models.CodeInvalidDomain:    "invalid email domain",
+		models.CodeEmailTooLong:     "email is too long",
+		models.CodeBlockedDomain:    "email domain is not allowed",
+		models.CodeInvalidAge:       "invalid age",
+		models.CodeInvalidBirthDate: "invalid birth date",
+		models.CodeFutureBirthDate:  "birth date is in the future",
+		models.CodeUnknownAgeGroup:  "unknown age group",
+		models.CodeMixedScriptName:  "a word in the name mixes letters from different alphabets",
+	},
+	"kk": {
+		models.CodeEmptyID:          "id бос",
+		models.CodeEmptyName:        "аты бос",
+		models.CodeEmptyEmail:       "email бос",
+		models.CodeInvalidEmail:     "email дұрыс емес",
+		models.CodeInvalidDomain:    "email домені дұрыс емес",
+		models.CodeEmailTooLong:     "email тым ұзын",
+		models.CodeBlockedDomain:    "email доменіне тыйым салынған",
+		models.CodeInvalidAge:       "жасы дұрыс емес",
+		models.CodeInvalidBirthDate: "туған күні дұрыс емес",
+		models.CodeFutureBirthDate:  "туған күні болашақта",
+		models.CodeUnknownAgeGroup:  "жас тобы белгісіз",
+		models.CodeMixedScriptName:  "аттағы сөзде әртүрлі әліпбилердің әріптері араласқан",
+	},
+}
+
+// validationError привязывает ошибку валидации к записи и переводит сообщение на язык конвертера
+func (c *Converter) validationError(index int, userID string, err error) *models.ValidationError {
+	validationErr := models.NewValidationError(index, userID, err)
+	if message, ok := validationMessages[c.language][validationErr.Code]; ok {
+		validationErr.Message = message
+	}
+	return validationErr
+}
+
+// warning привязывает предупреждение к записи и переводит сообщение на язык конвертера
+func (c *Converter) warning(index int, userID string, kind *models.Warning, value string) *models.Warning {
+	warning := models.NewWarning(index, userID, kind, value)
+	if message, ok := validationMessages[c.language][warning.Code]; ok {
+		warning.Message = message
+	}
+	return warning
+}
@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WithNameTitleCase включает приведение имен к виду «Иван Петров»
+func WithNameTitleCase(enabled bool) Option {
+	return func(c *Converter) {
+		c.titleCase = enabled
+	}
+}
+
+// WithHomoglyphWarnings включает предупреждения об именах, в словах которых
+// смешаны буквы латиницы, кириллицы или греческого алфавита
+func WithHomoglyphWarnings(enabled bool) Option {
+	return func(c *Converter) {
+		c.homoglyphs = enabled
+	}
+}
+
+// NormalizeName очищает имя: пробельные символы, включая неразрывные, заменяются
+// обычным пробелом и схлопываются, невидимые символы нулевой ширины и управляющие
+// символы удаляются, результат приводится к форме NFC.
+func NormalizeName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+
+	space := false
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r), unicode.In(r, unicode.Zs):
+			space = b.Len() > 0
+		case r == '\u200b', r == '\u2060', r == '\ufeff', unicode.IsControl(r): // ZWSP, word joiner, BOM
+		default:
+			if space {
+				b.WriteByte(' ')
+				space = false
+			}
+			b.WriteRune(r)
+		}
+	}
+	// Нормализуем после очистки: удаленный символ мог разделять букву и диакритику
+	return norm.NFC.String(b.String())
+}
+
+// titleCaseName делает первую букву каждого слова заглавной, остальные - строчными.
+// Слова разделяются пробелом и дефисом: «анна-мария ИВАНОВА» -> «Анна-Мария Иванова».
+// Апостроф словом не разделяется, чтобы не портить имена вроде «Мар'яна».
+func titleCaseName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+
+	start := true
+	for _, r := range name {
+		switch {
+		case start && unicode.IsLetter(r):
+			b.WriteRune(unicode.ToTitle(r))
+			start = false
+		case r == ' ' || r == '-':
+			b.WriteRune(r)
+			start = true
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// mixedScriptWord возвращает первое слово имени, в котором смешаны буквы
+// латиницы, кириллицы или греческого алфавита (например, «Ивaн» с латинской a)
+func mixedScriptWord(name string) string {
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == ' ' || r == '-' }) {
+		var scripts int
+		for _, r := range word {
+			switch {
+			case unicode.In(r, unicode.Latin):
+				scripts |= 1
+			case unicode.In(r, unicode.Cyrillic):
+				scripts |= 2
+			case unicode.In(r, unicode.Greek):
+				scripts |= 4
+			}
+		}
+		if scripts&(scripts-1) != 0 {
+			return word
+		}
+	}
+	return ""
+}
+
+// normalizeName очищает имя и, если включено, приводит его к виду «Иван Петров»
+func (c *Converter) normalizeName(name string) string {
+	name = NormalizeName(name)
+	if c.titleCase {
+		name = titleCaseName(name)
+	}
+	return name
+}
+
+// nameWarning возвращает предупреждение о смешении алфавитов в имени или nil
+func (c *Converter) nameWarning(index int, user models.XMLUser) error {
+	if !c.homoglyphs {
+		return nil
+	}
+	word := mixedScriptWord(user.Name)
+	if word == "" {
+		return nil
+	}
+	warning := c.warning(index, user.ID, models.WarnMixedScriptName, word)
+	warning.Position = user.Position
+	return warning
+}
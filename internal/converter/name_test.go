@@ -0,0 +1,103 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "без изменений", input: "Иван Петров", expected: "Иван Петров"},
+		{name: "пробелы по краям", input: "  Иван Петров\t", expected: "Иван Петров"},
+		{name: "двойные пробелы", input: "Иван   Петров", expected: "Иван Петров"},
+		{name: "неразрывный пробел", input: "Иван\u00a0Петров", expected: "Иван Петров"},
+		{name: "узкий неразрывный пробел", input: "Иван\u202f \u00a0Петров", expected: "Иван Петров"},
+		{name: "перевод строки", input: "Иван\r\nПетров", expected: "Иван Петров"},
+		{name: "пробел нулевой ширины", input: "Ив\u200bан\ufeff", expected: "Иван"},
+		{name: "разложенная й", input: "Андре\u0438\u0306", expected: "Андрей"},
+		{name: "разложенная ё", input: "Сем\u0435\u0308н", expected: "Семён"},
+		{name: "диакритика после удаленного символа", input: "Jose\u200b\u0301", expected: "Jos\u00e9"},
+		{name: "пустое имя", input: " \u00a0 ", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeName(tt.input))
+		})
+	}
+}
+
+func TestTitleCaseName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "иван петров", expected: "Иван Петров"},
+		{input: "ИВАН ПЕТРОВ", expected: "Иван Петров"},
+		{input: "анна-мария иванова", expected: "Анна-Мария Иванова"},
+		{input: "мар'яна", expected: "Мар'яна"},
+		{input: "«иван»", expected: "«Иван»"},
+		{input: "ǆuro", expected: "ǅuro"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, titleCaseName(tt.input))
+		})
+	}
+}
+
+func TestMixedScriptWord(t *testing.T) {
+	assert.Equal(t, "", mixedScriptWord("Иван Петров"))
+	assert.Equal(t, "", mixedScriptWord("Ivan Петров"), "слова на разных алфавитах допустимы")
+	assert.Equal(t, "Ивaн", mixedScriptWord("Ивaн Петров"), "латинская a в кириллическом слове")
+	assert.Equal(t, "Рetrov", mixedScriptWord("Ivan Рetrov"), "кириллическая Р в латинском слове")
+	assert.Equal(t, "Πетров", mixedScriptWord("Анна-Πетров"), "греческая П в кириллическом слове")
+}
+
+func TestConverter_UsersXMLToJSON_Names(t *testing.T) {
+	users := &models.XMLUsers{
+		Users: []models.XMLUser{
+			{ID: "1", Name: "иван\u00a0\u00a0ПЕТРОВ", Email: "ivan@example.com", Age: 30},
+			{ID: "2", Name: "Мaрия  Петрова", Email: "maria@example.com", Age: 25},
+			{ID: "3", Name: " \u200b\u00a0", Email: "petr@example.com", Age: 40},
+		},
+	}
+
+	t.Run("по умолчанию", func(t *testing.T) {
+		result, err := NewConverter().UsersXMLToJSON(users)
+
+		require.Len(t, result, 2)
+		assert.Equal(t, "иван ПЕТРОВ", result[0].FullName)
+		assert.Equal(t, "Мaрия Петрова", result[1].FullName)
+		assert.ErrorIs(t, err, models.ErrEmptyName, "имя из одних пробелов пустое")
+		assert.Empty(t, WarningReport(err))
+	})
+
+	t.Run("заглавные буквы и предупреждения", func(t *testing.T) {
+		converter := NewConverter(WithNameTitleCase(true), WithHomoglyphWarnings(true), WithLanguage("en"))
+
+		result, err := converter.UsersXMLToJSON(users)
+
+		require.Len(t, result, 2)
+		assert.Equal(t, "Иван Петров", result[0].FullName)
+		assert.Equal(t, "Мaрия Петрова", result[1].FullName, "имя с предупреждением не отбрасывается")
+		assert.ErrorIs(t, err, models.WarnMixedScriptName)
+		assert.Equal(t, []models.Warning{{
+			Index:   1,
+			UserID:  "2",
+			Field:   "name",
+			Code:    models.CodeMixedScriptName,
+			Message: "a word in the name mixes letters from different alphabets",
+			Value:   "Мaрия",
+		}}, WarningReport(err))
+		assert.Len(t, ValidationReport(err), 1)
+	})
+}
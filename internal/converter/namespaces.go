@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Пространства имен SOAP конвертов
+const (
+	SOAP11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	SOAP12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// WithNamespaces задает пространства имен, в которых принимаются элементы
+// коллекции и записей пользователей. Элементы без пространства имен принимаются всегда,
+// пустой список разрешает любое пространство имен.
+func WithNamespaces(uris ...string) Option {
+	return func(c *Converter) {
+		c.namespaces = namespaceSet(uris)
+	}
+}
+
+// ParseNamespaces разбирает список пространств имен, разделенных запятыми
+func ParseNamespaces(list string) []string {
+	var uris []string
+	for _, uri := range strings.Split(list, ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// namespaceSet строит множество пространств имен; пустой список - nil (любые)
+func namespaceSet(uris []string) map[string]struct{} {
+	if len(uris) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(uris))
+	for _, uri := range uris {
+		set[uri] = struct{}{}
+	}
+	return set
+}
+
+// checkNamespace проверяет, что элемент находится в разрешенном пространстве имен
+func (d *UserDecoder) checkNamespace(name xml.Name) error {
+	if name.Space == "" || d.namespaces == nil {
+		return nil
+	}
+	if _, ok := d.namespaces[name.Space]; ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %w: <%s> в %q", models.ErrInvalidXML, models.ErrNamespaceNotAccepted, name.Local, name.Space)
+}
+
+// isSOAP сообщает, что элемент принадлежит SOAP конверту
+func isSOAP(name xml.Name) bool {
+	return name.Space == SOAP11Namespace || name.Space == SOAP12Namespace
+}
+
+// soapFault - описание ошибки SOAP 1.1 и 1.2
+type soapFault struct {
+	Code   string `xml:"faultcode"`
+	String string `xml:"faultstring"`
+	Reason string `xml:"Reason>Text"`
+}
+
+// decodeSOAPFault читает элемент Fault и превращает его в ошибку
+func (d *UserDecoder) decodeSOAPFault(start *xml.StartElement) error {
+	var fault soapFault
+	if err := d.decoder.DecodeElement(&fault, start); err != nil {
+		return fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+	}
+
+	message := fault.String
+	if message == "" {
+		message = strings.TrimSpace(fault.Reason)
+	}
+	return fmt.Errorf("%w: %w: %s %s", models.ErrInvalidXML, models.ErrSOAPFault, fault.Code, message)
+}
@@ -0,0 +1,136 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const namespacedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<ns2:users xmlns:ns2="urn:hr">
+    <ns2:user ns2:id="1">
+        <ns2:name>Иван Иванов</ns2:name>
+        <ns2:email>ivan@example.com</ns2:email>
+        <ns2:age>30</ns2:age>
+    </ns2:user>
+</ns2:users>`
+
+const soapXML = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+    <soap:Header><auth>secret</auth></soap:Header>
+    <soap:Body>
+        <users xmlns="urn:hr">
+            <user id="1">
+                <name>Иван Иванов</name>
+                <email>ivan@example.com</email>
+                <age>30</age>
+            </user>
+            <user id="2">
+                <name>Мария Петрова</name>
+                <email>maria@example.com</email>
+                <age>25</age>
+            </user>
+        </users>
+    </soap:Body>
+</soap:Envelope>`
+
+func TestConverter_ParseXML_Namespaces(t *testing.T) {
+	t.Run("пространство имен с префиксом", func(t *testing.T) {
+		result, err := NewConverter().ParseXML([]byte(namespacedXML))
+
+		require.NoError(t, err)
+		require.Len(t, result.Users, 1)
+		assert.Equal(t, "urn:hr", result.XMLName.Space)
+		assert.Equal(t, models.XMLUser{
+			ID:       "1",
+			Name:     "Иван Иванов",
+			Email:    "ivan@example.com",
+			Age:      30,
+			AgeText:  "30",
+			Position: models.Position{Line: 3, Column: 5, Snippet: `<ns2:user ns2:id="1">`},
+		}, result.Users[0])
+	})
+
+	t.Run("SOAP конверт", func(t *testing.T) {
+		result, err := NewConverter().ParseXML([]byte(soapXML))
+
+		require.NoError(t, err)
+		require.Len(t, result.Users, 2)
+		assert.Equal(t, "users", result.XMLName.Local)
+		assert.Equal(t, "Мария Петрова", result.Users[1].Name)
+	})
+
+	t.Run("SOAP 1.2 конверт", func(t *testing.T) {
+		result, err := NewConverter().ParseXML([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+    <env:Body><users><user id="1"><name>Иван</name></user></users></env:Body>
+</env:Envelope>`))
+
+		require.NoError(t, err)
+		require.Len(t, result.Users, 1)
+	})
+}
+
+func TestConverter_ParseXML_AcceptedNamespaces(t *testing.T) {
+	converter := NewConverter(WithNamespaces(ParseNamespaces(" urn:hr , urn:erp")...))
+
+	_, err := converter.ParseXML([]byte(namespacedXML))
+	assert.NoError(t, err)
+
+	_, err = converter.ParseXML([]byte(soapXML))
+	assert.NoError(t, err)
+
+	// Документ без пространства имен принимается всегда
+	_, err = converter.ParseXML([]byte(`<users><user id="1"><name>Иван</name></user></users>`))
+	assert.NoError(t, err)
+
+	_, err = converter.ParseXML([]byte(`<users xmlns="urn:other"><user id="1"><name>Иван</name></user></users>`))
+	assert.ErrorIs(t, err, models.ErrInvalidXML)
+	assert.ErrorIs(t, err, models.ErrNamespaceNotAccepted)
+
+	// Записи тоже проверяются
+	_, err = converter.ParseXML([]byte(`<users xmlns:o="urn:other"><o:user id="1"><name>Иван</name></o:user></users>`))
+	assert.ErrorIs(t, err, models.ErrNamespaceNotAccepted)
+}
+
+func TestConverter_ParseXML_SOAPErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		xmlData  string
+		expected error
+		contains string
+	}{
+		{
+			name: "SOAP Fault",
+			xmlData: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>
+    <soap:Fault><faultcode>soap:Server</faultcode><faultstring>Сервис недоступен</faultstring></soap:Fault>
+</soap:Body></soap:Envelope>`,
+			expected: models.ErrSOAPFault,
+			contains: "Сервис недоступен",
+		},
+		{
+			name:     "пустое тело конверта",
+			xmlData:  `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body></soap:Body></soap:Envelope>`,
+			expected: models.ErrInvalidXML,
+			contains: "element <users> not found",
+		},
+		{
+			name:     "чужой элемент в конверте",
+			xmlData:  `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><orders/></soap:Body></soap:Envelope>`,
+			expected: models.ErrInvalidXML,
+			contains: "have <orders>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConverter().UsersXMLStreamToJSON(strings.NewReader(tt.xmlData))
+
+			assert.ErrorIs(t, err, tt.expected)
+			assert.ErrorIs(t, err, models.ErrInvalidXML)
+			assert.Contains(t, err.Error(), tt.contains)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Режимы переноса нераспознанных элементов и атрибутов <user> в JSON
+const (
+	PassthroughOff     = ""        // Нераспознанные данные отбрасываются
+	PassthroughFlatten = "flatten" // Добавляются в объект пользователя на верхнем уровне
+	PassthroughExtra   = "extra"   // Добавляются в объект под ключом PassthroughExtraKey
+)
+
+// PassthroughExtraKey - ключ для нераспознанных данных в режиме PassthroughExtra
+const PassthroughExtraKey = "extra"
+
+// WithPassthrough задает режим переноса нераспознанных элементов в JSON
+func WithPassthrough(mode string) Option {
+	return func(c *Converter) {
+		c.passthrough = mode
+	}
+}
+
+// ValidatePassthrough проверяет название режима переноса
+func ValidatePassthrough(mode string) error {
+	switch mode {
+	case PassthroughOff, PassthroughFlatten, PassthroughExtra:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", models.ErrInvalidPassthrough, mode)
+	}
+}
+
+// knownUser - запись пользователя без нераспознанных данных. Поля верхнего уровня
+// перекрывают UnknownAttrs и UnknownElements из models.XMLUser, поэтому без переноса
+// декодер пропускает нераспознанное, не собирая из него дерево для каждой записи.
+type knownUser struct {
+	*models.XMLUser
+	UnknownAttrs    skippedAttr    `xml:",any,attr"`
+	UnknownElements skippedElement `xml:",any"`
+}
+
+// skippedAttr - нераспознанный атрибут, который не сохраняется
+type skippedAttr struct{}
+
+// UnmarshalXMLAttr отбрасывает атрибут
+func (skippedAttr) UnmarshalXMLAttr(xml.Attr) error {
+	return nil
+}
+
+// skippedElement - нераспознанный элемент, который не сохраняется
+type skippedElement struct{}
+
+// UnmarshalXML пропускает элемент целиком
+func (skippedElement) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	return d.Skip()
+}
+
+// passthroughFields добавляет нераспознанные атрибуты и элементы пользователя в extra
+func (c *Converter) passthroughFields(user models.XMLUser, extra map[string]any) map[string]any {
+	if c.passthrough == PassthroughOff || (len(user.UnknownAttrs) == 0 && len(user.UnknownElements) == 0) {
+		return extra
+	}
+
+	fields := nodeFields(models.XMLNode{Attrs: user.UnknownAttrs, Nodes: user.UnknownElements})
+
+	if extra == nil {
+		extra = make(map[string]any, len(fields))
+	}
+	if c.passthrough == PassthroughExtra {
+		extra[PassthroughExtraKey] = fields
+		return extra
+	}
+	for key, value := range fields {
+		if _, ok := extra[key]; !ok {
+			extra[key] = value
+		}
+	}
+	return extra
+}
+
+// nodeFields превращает атрибуты и дочерние элементы в поля JSON объекта.
+// Элемент без вложенных элементов и атрибутов становится строкой, иначе - объектом
+// (текст элемента попадает в ключ value), повторяющиеся элементы собираются в массив.
+func nodeFields(node models.XMLNode) map[string]any {
+	fields := make(map[string]any, len(node.Attrs)+len(node.Nodes))
+	for _, attr := range node.Attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		fields[attr.Name.Local] = attr.Value
+	}
+	if text := strings.TrimSpace(node.Content); text != "" {
+		fields["value"] = text
+	}
+
+	for _, child := range node.Nodes {
+		var value any = strings.TrimSpace(child.Content)
+		if len(child.Nodes) > 0 || len(child.Attrs) > 0 {
+			value = nodeFields(child)
+		}
+
+		key := child.XMLName.Local
+		switch existing := fields[key].(type) {
+		case nil:
+			fields[key] = value
+		case []any:
+			fields[key] = append(existing, value)
+		default:
+			fields[key] = []any{existing, value}
+		}
+	}
+	return fields
+}
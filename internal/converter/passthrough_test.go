@@ -0,0 +1,174 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const passthroughXML = `<users>
+    <user id="1" source="hr" xmlns:hr="urn:hr">
+        <name>Иван Иванов</name>
+        <email>ivan@example.com</email>
+        <age>30</age>
+        <phone type="work">+7 700 000 00 01</phone>
+        <phone>+7 700 000 00 02</phone>
+        <department>Бухгалтерия</department>
+        <address><city>Алматы</city><street>Абая</street></address>
+    </user>
+</users>`
+
+func TestConverter_Passthrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{
+			name: "отключен",
+			mode: PassthroughOff,
+			expected: `{
+				"id": "1", "full_name": "Иван Иванов", "email": "ivan@example.com",
+				"age_group": "от 25 до 35", "age_group_code": "25_35"
+			}`,
+		},
+		{
+			name: "на верхнем уровне",
+			mode: PassthroughFlatten,
+			expected: `{
+				"id": "1", "full_name": "Иван Иванов", "email": "ivan@example.com",
+				"age_group": "от 25 до 35", "age_group_code": "25_35",
+				"source": "hr",
+				"phone": [{"type": "work", "value": "+7 700 000 00 01"}, "+7 700 000 00 02"],
+				"department": "Бухгалтерия",
+				"address": {"city": "Алматы", "street": "Абая"}
+			}`,
+		},
+		{
+			name: "под ключом extra",
+			mode: PassthroughExtra,
+			expected: `{
+				"id": "1", "full_name": "Иван Иванов", "email": "ivan@example.com",
+				"age_group": "от 25 до 35", "age_group_code": "25_35",
+				"extra": {
+					"source": "hr",
+					"phone": [{"type": "work", "value": "+7 700 000 00 01"}, "+7 700 000 00 02"],
+					"department": "Бухгалтерия",
+					"address": {"city": "Алматы", "street": "Абая"}
+				}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := NewConverter(WithPassthrough(tt.mode))
+
+			result, err := converter.UsersXMLStreamToJSON(strings.NewReader(passthroughXML))
+			require.NoError(t, err)
+			require.Len(t, result, 1)
+
+			data, err := json.Marshal(result[0])
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestConverter_Passthrough_StandardFieldsWin(t *testing.T) {
+	converter := NewConverter(WithPassthrough(PassthroughFlatten))
+
+	jsonUser := converter.UserXMLToJSON(models.XMLUser{
+		ID:    "1",
+		Name:  "Иван",
+		Email: "ivan@example.com",
+		Age:   30,
+		UnknownElements: []models.XMLNode{
+			{XMLName: xmlName("full_name"), Content: "Подмена"},
+		},
+	})
+
+	data, err := json.Marshal(jsonUser)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"full_name":"Иван"`)
+	assert.NotContains(t, string(data), "Подмена")
+}
+
+func TestValidatePassthrough(t *testing.T) {
+	assert.NoError(t, ValidatePassthrough(PassthroughOff))
+	assert.NoError(t, ValidatePassthrough(PassthroughFlatten))
+	assert.NoError(t, ValidatePassthrough(PassthroughExtra))
+	assert.ErrorIs(t, ValidatePassthrough("nested"), models.ErrInvalidPassthrough)
+}
+
+func xmlName(local string) xml.Name {
+	return xml.Name{Local: local}
+}
+
+// unknownUsersXML - документ, в каждой записи которого есть нераспознанные атрибуты и элементы
+func unknownUsersXML(count int) []byte {
+	var document strings.Builder
+	document.WriteString(`<?xml version="1.0" encoding="UTF-8"?><users>`)
+	for i := 1; i <= count; i++ {
+		fmt.Fprintf(&document, `<user id="%d" source="hr" branch="%d"><name>Пользователь %d</name><email>user%d@example.com</email><age>%d</age>`+
+			`<phone type="work">+7 700 000 %04d</phone><phone>+7 701 000 %04d</phone><department>Бухгалтерия</department>`+
+			`<address><city>Алматы</city><street>Абая</street><building>%d</building></address></user>`,
+			i, i%10, i, i, 20+(i%50), i%10000, i%10000, i)
+	}
+	document.WriteString(`</users>`)
+	return []byte(document.String())
+}
+
+func TestUserDecoder_PassthroughOff_SkipsUnknown(t *testing.T) {
+	decoder := NewConverter().NewUserDecoder(strings.NewReader(passthroughXML))
+
+	user, err := decoder.Next()
+
+	require.NoError(t, err)
+	assert.Equal(t, "1", user.ID)
+	assert.Equal(t, "Иван Иванов", user.Name)
+	assert.Equal(t, 30, user.Age)
+	assert.Nil(t, user.UnknownAttrs)
+	assert.Nil(t, user.UnknownElements)
+}
+
+// Без переноса нераспознанные данные пропускаются при чтении: декодер не собирает
+// из них дерево, поэтому аллокаций на запись заметно меньше, чем с переносом
+func TestUserDecoder_Passthrough_AllocsPerRecord(t *testing.T) {
+	const count = 1000
+	document := unknownUsersXML(count)
+	allocsPerRecord := func(converter *Converter) float64 {
+		allocs := testing.AllocsPerRun(5, func() {
+			decoder := converter.NewUserDecoder(bytes.NewReader(document))
+			records := 0
+			for {
+				_, err := decoder.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				records++
+			}
+			if records != count {
+				t.Fatalf("records = %d", records)
+			}
+		})
+		return allocs / count
+	}
+
+	off := allocsPerRecord(NewConverter())
+	on := allocsPerRecord(NewConverter(WithPassthrough(PassthroughFlatten)))
+
+	t.Logf("аллокаций на запись: %.2f (без переноса), %.2f (с переносом)", off, on)
+	assert.Less(t, off, on*0.85, "без переноса нераспознанные данные не должны сохраняться")
+}
@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// snippetLength - сколько байт строки документа попадает во фрагмент в ошибках
+const snippetLength = 120
+
+// positionReader отдает документ декодеру побайтово и помнит конец текущей строки,
+// чтобы показать фрагмент документа в ошибках. Реализует io.ByteReader, поэтому
+// xml.Decoder читает из него без собственного буфера и позиции совпадают.
+type positionReader struct {
+	r       *bufio.Reader
+	line    []byte // Последние байты текущей строки (не больше 2*snippetLength)
+	lineLen int    // Сколько байт текущей строки прочитано
+
+	record    models.Position // Начало текущей записи
+	capture   []byte          // Начало строки текущей записи
+	capturing bool
+}
+
+func newPositionReader(r io.Reader) *positionReader {
+	return &positionReader{r: bufio.NewReader(r)}
+}
+
+func (p *positionReader) ReadByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return b, err
+	}
+
+	if b == '\n' {
+		p.line = p.line[:0]
+		p.lineLen = 0
+		p.capturing = false
+		return b, nil
+	}
+	if len(p.line) == 2*snippetLength {
+		p.line = append(p.line[:0], p.line[snippetLength:]...)
+	}
+	p.line = append(p.line, b)
+	p.lineLen++
+	if p.capturing && len(p.capture) < snippetLength {
+		p.capture = append(p.capture, b)
+	}
+	return b, nil
+}
+
+func (p *positionReader) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+// charsetReader перекодирует остаток документа по объявлению <?xml encoding="..."?>.
+// Перекодированный текст читается через этот же positionReader.
+func (p *positionReader) charsetReader(charset string, _ io.Reader) (io.Reader, error) {
+	decoded, err := CharsetReader(charset, p.r)
+	if err != nil {
+		return nil, err
+	}
+	p.r = bufio.NewReader(decoded)
+	return p, nil
+}
+
+// startRecord запоминает начало записи: строку, колонку и текст строки с этой колонки.
+// Открывающий тег к этому моменту уже прочитан и лежит в конце p.line.
+func (p *positionReader) startRecord(line, column int) {
+	p.record = models.Position{Line: line, Column: column}
+	p.capture = p.capture[:0]
+	p.capturing = true
+
+	if start := column - 1 - (p.lineLen - len(p.line)); start >= 0 && start <= len(p.line) {
+		p.capture = append(p.capture, p.line[start:]...)
+	}
+}
+
+// recordPosition возвращает место текущей записи с фрагментом ее первой строки
+func (p *positionReader) recordPosition() models.Position {
+	position := p.record
+	position.Snippet = snippet(p.capture)
+	return position
+}
+
+// errorPosition возвращает место ошибки с фрагментом строки вокруг него.
+// Дочитывает строку за позицией, поэтому вызывается, только когда разбор остановлен.
+func (p *positionReader) errorPosition(line, column int) models.Position {
+	text := append([]byte(nil), p.line...)
+	if len(text) > snippetLength {
+		text = text[len(text)-snippetLength:]
+	}
+	for ahead := 0; ahead < snippetLength/2; ahead++ {
+		b, err := p.r.ReadByte()
+		if err != nil || b == '\n' {
+			break
+		}
+		text = append(text, b)
+	}
+	return models.Position{Line: line, Column: column, Snippet: snippet(text)}
+}
+
+// snippet превращает байты строки в фрагмент для ошибки.
+// Окно могло разрезать многобайтовый символ, такие байты отбрасываются.
+func snippet(text []byte) string {
+	return strings.TrimSpace(strings.ToValidUTF8(string(text), ""))
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestConverter_ParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		xml      string
+		expected models.Position
+	}{
+		{
+			name:     "непарный тег",
+			xml:      "<users>\n  <user id=\"1\">\n    <name>Иван</email>\n  </user>\n</users>",
+			expected: models.Position{Line: 3, Column: 27, Snippet: "<name>Иван</email>"},
+		},
+		{
+			name:     "недопустимый символ в имени тега",
+			xml:      "<users>\n  <user id=\"1\"><name>Иван</name><@email>x</email></user>\n</users>",
+			expected: models.Position{Line: 2, Column: 38, Snippet: `<user id="1"><name>Иван</name><@email>x</email></user>`},
+		},
+		{
+			name:     "атрибут без кавычек",
+			xml:      "<users>\n\n  <user id=1><name>Иван</name></user>\n</users>",
+			expected: models.Position{Line: 3, Column: 13, Snippet: "<user id=1><name>Иван</name></user>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConverter().UsersXMLStreamToJSON(strings.NewReader(tt.xml))
+
+			assert.ErrorIs(t, err, models.ErrInvalidXML)
+			var positionErr *models.PositionError
+			require.ErrorAs(t, err, &positionErr)
+			assert.Equal(t, tt.expected, positionErr.Position)
+			assert.Contains(t, err.Error(), "строка")
+		})
+	}
+}
+
+func TestConverter_ParseErrorPosition_LongLine(t *testing.T) {
+	// Весь документ в одну строку: во фрагмент попадает только окрестность ошибки
+	var b strings.Builder
+	b.WriteString("<users>")
+	for range 100 {
+		b.WriteString(`<user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>`)
+	}
+	b.WriteString(`<user id="2"><name>Мария</nam></user></users>`)
+
+	_, err := NewConverter().UsersXMLStreamToJSON(strings.NewReader(b.String()))
+
+	var positionErr *models.PositionError
+	require.ErrorAs(t, err, &positionErr)
+	assert.Equal(t, 1, positionErr.Line)
+	assert.Equal(t, len(b.String())-len("</user></users>")+1, positionErr.Column)
+	assert.LessOrEqual(t, len(positionErr.Snippet), snippetLength+snippetLength/2)
+	assert.True(t, strings.HasSuffix(positionErr.Snippet, "<name>Мария</nam></user></users>"), positionErr.Snippet)
+}
+
+func TestConverter_ValidationReport_Position(t *testing.T) {
+	xml := `<users>
+  <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+  <user id="2">
+    <name>Мария</name>
+    <email></email>
+    <age>25</age>
+  </user>
+  <user id="3"><name>Петр</name><email>petr@example.com</email><age>300</age></user>
+</users>`
+
+	_, err := NewConverter().UsersXMLStreamToJSON(strings.NewReader(xml))
+
+	report := ValidationReport(err)
+	require.Len(t, report, 2)
+	assert.Equal(t, models.Position{Line: 3, Column: 3, Snippet: `<user id="2">`}, report[0].Position)
+	assert.Equal(t, models.Position{
+		Line:    8,
+		Column:  3,
+		Snippet: `<user id="3"><name>Петр</name><email>petr@example.com</email><age>300</age></user>`,
+	}, report[1].Position)
+	assert.Contains(t, report[1].Error(), "строка 8")
+}
+
+func TestConverter_ParseErrorPosition_Charset(t *testing.T) {
+	data, err := charmap.Windows1251.NewEncoder().String(`<?xml version="1.0" encoding="windows-1251"?>
+<users>
+  <user id="1"><name>Иван</name></email></user>
+</users>`)
+	require.NoError(t, err)
+
+	_, err = NewConverter().UsersXMLStreamToJSON(strings.NewReader(data))
+
+	var positionErr *models.PositionError
+	require.ErrorAs(t, err, &positionErr)
+	assert.Equal(t, 3, positionErr.Line)
+	assert.Equal(t, `<user id="1"><name>Иван</name></email></user>`, positionErr.Snippet, "фрагмент в UTF-8")
+}
@@ -0,0 +1,391 @@
+package converter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// Schema - скомпилированное подмножество XSD схемы.
+// Поддерживаются глобальные элементы, complexType с xs:sequence и атрибутами,
+// minOccurs/maxOccurs, встроенные типы и simpleType с ограничениями
+// pattern, enumeration, minInclusive/maxInclusive, minLength/maxLength.
+type Schema struct {
+	elements map[string]*schemaElement
+}
+
+// schemaElement - объявление элемента
+type schemaElement struct {
+	name      string
+	minOccurs int
+	maxOccurs int // -1 - unbounded
+	simple    *schemaSimpleType
+	complex   *schemaComplexType
+}
+
+// schemaComplexType - элемент с дочерними элементами и атрибутами
+type schemaComplexType struct {
+	sequence   []*schemaElement
+	attributes []*schemaAttribute
+}
+
+// schemaAttribute - объявление атрибута
+type schemaAttribute struct {
+	name     string
+	required bool
+	simple   *schemaSimpleType
+}
+
+// schemaSimpleType - простой тип: встроенный тип с ограничениями
+type schemaSimpleType struct {
+	name         string
+	builtin      string // Встроенный тип XSD без префикса: string, integer, date...
+	base         *schemaSimpleType
+	patterns     []*regexp.Regexp
+	enumeration  []string
+	minInclusive *float64
+	maxInclusive *float64
+	minLength    *int
+	maxLength    *int
+}
+
+// Встроенные типы XSD, которые умеет проверять валидатор
+var schemaBuiltinTypes = map[string]struct{}{
+	"string": {}, "normalizedString": {}, "token": {}, "anyURI": {},
+	"integer": {}, "int": {}, "long": {}, "short": {},
+	"positiveInteger": {}, "nonNegativeInteger": {},
+	"decimal": {}, "float": {}, "double": {},
+	"boolean": {}, "date": {}, "dateTime": {},
+}
+
+// Структуры для чтения XSD документа
+type (
+	xsdSchema struct {
+		Elements     []xsdElement     `xml:"element"`
+		ComplexTypes []xsdComplexType `xml:"complexType"`
+		SimpleTypes  []xsdSimpleType  `xml:"simpleType"`
+	}
+
+	xsdElement struct {
+		Name        string          `xml:"name,attr"`
+		Type        string          `xml:"type,attr"`
+		MinOccurs   string          `xml:"minOccurs,attr"`
+		MaxOccurs   string          `xml:"maxOccurs,attr"`
+		ComplexType *xsdComplexType `xml:"complexType"`
+		SimpleType  *xsdSimpleType  `xml:"simpleType"`
+	}
+
+	xsdComplexType struct {
+		Name       string         `xml:"name,attr"`
+		Sequence   *xsdSequence   `xml:"sequence"`
+		Attributes []xsdAttribute `xml:"attribute"`
+	}
+
+	xsdSequence struct {
+		Elements []xsdElement `xml:"element"`
+	}
+
+	xsdAttribute struct {
+		Name       string         `xml:"name,attr"`
+		Type       string         `xml:"type,attr"`
+		Use        string         `xml:"use,attr"`
+		SimpleType *xsdSimpleType `xml:"simpleType"`
+	}
+
+	xsdSimpleType struct {
+		Name        string          `xml:"name,attr"`
+		Restriction *xsdRestriction `xml:"restriction"`
+	}
+
+	xsdRestriction struct {
+		Base         string     `xml:"base,attr"`
+		Patterns     []xsdFacet `xml:"pattern"`
+		Enumerations []xsdFacet `xml:"enumeration"`
+		MinInclusive *xsdFacet  `xml:"minInclusive"`
+		MaxInclusive *xsdFacet  `xml:"maxInclusive"`
+		MinLength    *xsdFacet  `xml:"minLength"`
+		MaxLength    *xsdFacet  `xml:"maxLength"`
+	}
+
+	xsdFacet struct {
+		Value string `xml:"value,attr"`
+	}
+)
+
+// LoadSchema загружает XSD схему из файла
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema компилирует XSD схему из данных
+func ParseSchema(data []byte) (*Schema, error) {
+	var doc xsdSchema
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidSchema, err)
+	}
+
+	b := &schemaBuilder{
+		doc:     &doc,
+		simple:  make(map[string]*schemaSimpleType),
+		complex: make(map[string]*schemaComplexType),
+	}
+	return b.build()
+}
+
+// schemaBuilder разрешает ссылки на именованные типы при компиляции схемы
+type schemaBuilder struct {
+	doc     *xsdSchema
+	simple  map[string]*schemaSimpleType
+	complex map[string]*schemaComplexType
+}
+
+func (b *schemaBuilder) build() (*Schema, error) {
+	if len(b.doc.Elements) == 0 {
+		return nil, fmt.Errorf("%w: нет ни одного глобального элемента", models.ErrInvalidSchema)
+	}
+
+	schema := &Schema{elements: make(map[string]*schemaElement, len(b.doc.Elements))}
+	for _, raw := range b.doc.Elements {
+		element, err := b.element(raw, 0)
+		if err != nil {
+			return nil, err
+		}
+		// У глобальных элементов нет minOccurs/maxOccurs
+		element.minOccurs, element.maxOccurs = 1, 1
+		schema.elements[element.name] = element
+	}
+	return schema, nil
+}
+
+// Предел вложенности типов: защищает от рекурсивных определений
+const schemaMaxDepth = 32
+
+func (b *schemaBuilder) element(raw xsdElement, depth int) (*schemaElement, error) {
+	if raw.Name == "" {
+		return nil, fmt.Errorf("%w: элемент без имени", models.ErrInvalidSchema)
+	}
+	if depth > schemaMaxDepth {
+		return nil, fmt.Errorf("%w: %q: слишком глубокая вложенность типов", models.ErrInvalidSchema, raw.Name)
+	}
+
+	element := &schemaElement{name: raw.Name, minOccurs: 1, maxOccurs: 1}
+	if raw.MinOccurs != "" {
+		n, err := strconv.Atoi(raw.MinOccurs)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: %q: некорректный minOccurs %q", models.ErrInvalidSchema, raw.Name, raw.MinOccurs)
+		}
+		element.minOccurs = n
+	}
+	if raw.MaxOccurs == "unbounded" {
+		element.maxOccurs = -1
+	} else if raw.MaxOccurs != "" {
+		n, err := strconv.Atoi(raw.MaxOccurs)
+		if err != nil || n < 1 || n < element.minOccurs {
+			return nil, fmt.Errorf("%w: %q: некорректный maxOccurs %q", models.ErrInvalidSchema, raw.Name, raw.MaxOccurs)
+		}
+		element.maxOccurs = n
+	}
+
+	var err error
+	switch {
+	case raw.ComplexType != nil:
+		element.complex, err = b.complexType(raw.ComplexType, depth+1)
+	case raw.SimpleType != nil:
+		element.simple, err = b.simpleType(raw.SimpleType, depth+1)
+	case raw.Type != "":
+		element.simple, element.complex, err = b.resolve(raw.Type, depth+1)
+	default:
+		// Тип не указан - любое текстовое содержимое
+		element.simple = &schemaSimpleType{name: "string", builtin: "string"}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", raw.Name, err)
+	}
+	return element, nil
+}
+
+// resolve находит тип по имени: встроенный или объявленный в схеме
+func (b *schemaBuilder) resolve(qname string, depth int) (*schemaSimpleType, *schemaComplexType, error) {
+	name := localName(qname)
+
+	if _, ok := schemaBuiltinTypes[name]; ok {
+		return &schemaSimpleType{name: name, builtin: name}, nil, nil
+	}
+	for i := range b.doc.SimpleTypes {
+		if b.doc.SimpleTypes[i].Name == name {
+			simple, err := b.simpleType(&b.doc.SimpleTypes[i], depth)
+			return simple, nil, err
+		}
+	}
+	for i := range b.doc.ComplexTypes {
+		if b.doc.ComplexTypes[i].Name == name {
+			complex, err := b.complexType(&b.doc.ComplexTypes[i], depth)
+			return nil, complex, err
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: неизвестный тип %q", models.ErrInvalidSchema, qname)
+}
+
+func (b *schemaBuilder) complexType(raw *xsdComplexType, depth int) (*schemaComplexType, error) {
+	if raw.Name != "" {
+		if complex, ok := b.complex[raw.Name]; ok {
+			return complex, nil
+		}
+	}
+
+	complex := &schemaComplexType{}
+	if raw.Sequence != nil {
+		for _, rawChild := range raw.Sequence.Elements {
+			child, err := b.element(rawChild, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			complex.sequence = append(complex.sequence, child)
+		}
+	}
+
+	for _, rawAttr := range raw.Attributes {
+		if rawAttr.Name == "" {
+			return nil, fmt.Errorf("%w: атрибут без имени", models.ErrInvalidSchema)
+		}
+		attr := &schemaAttribute{name: rawAttr.Name, required: rawAttr.Use == "required"}
+
+		var err error
+		switch {
+		case rawAttr.SimpleType != nil:
+			attr.simple, err = b.simpleType(rawAttr.SimpleType, depth+1)
+		case rawAttr.Type != "":
+			var complexAttr *schemaComplexType
+			attr.simple, complexAttr, err = b.resolve(rawAttr.Type, depth+1)
+			if err == nil && complexAttr != nil {
+				err = fmt.Errorf("%w: атрибут %q не может иметь сложный тип", models.ErrInvalidSchema, rawAttr.Name)
+			}
+		default:
+			attr.simple = &schemaSimpleType{name: "string", builtin: "string"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		complex.attributes = append(complex.attributes, attr)
+	}
+
+	if raw.Name != "" {
+		b.complex[raw.Name] = complex
+	}
+	return complex, nil
+}
+
+func (b *schemaBuilder) simpleType(raw *xsdSimpleType, depth int) (*schemaSimpleType, error) {
+	if raw.Name != "" {
+		if simple, ok := b.simple[raw.Name]; ok {
+			return simple, nil
+		}
+	}
+	if depth > schemaMaxDepth {
+		return nil, fmt.Errorf("%w: %q: слишком глубокая вложенность типов", models.ErrInvalidSchema, raw.Name)
+	}
+
+	restriction := raw.Restriction
+	if restriction == nil || restriction.Base == "" {
+		return nil, fmt.Errorf("%w: simpleType %q без restriction base", models.ErrInvalidSchema, raw.Name)
+	}
+
+	base, complex, err := b.resolve(restriction.Base, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if complex != nil {
+		return nil, fmt.Errorf("%w: simpleType %q основан на сложном типе", models.ErrInvalidSchema, raw.Name)
+	}
+
+	simple := &schemaSimpleType{name: raw.Name, builtin: base.builtin, base: base}
+	if simple.name == "" {
+		simple.name = base.name
+	}
+
+	for _, pattern := range restriction.Patterns {
+		// Шаблоны XSD всегда сопоставляются со всем значением
+		re, err := regexp.Compile("^(?:" + pattern.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("%w: некорректный pattern %q: %w", models.ErrInvalidSchema, pattern.Value, err)
+		}
+		simple.patterns = append(simple.patterns, re)
+	}
+	for _, enumeration := range restriction.Enumerations {
+		simple.enumeration = append(simple.enumeration, enumeration.Value)
+	}
+
+	if simple.minInclusive, err = facetFloat(restriction.MinInclusive); err != nil {
+		return nil, err
+	}
+	if simple.maxInclusive, err = facetFloat(restriction.MaxInclusive); err != nil {
+		return nil, err
+	}
+	if simple.minLength, err = facetInt(restriction.MinLength); err != nil {
+		return nil, err
+	}
+	if simple.maxLength, err = facetInt(restriction.MaxLength); err != nil {
+		return nil, err
+	}
+
+	if raw.Name != "" {
+		b.simple[raw.Name] = simple
+	}
+	return simple, nil
+}
+
+func facetFloat(facet *xsdFacet) (*float64, error) {
+	if facet == nil {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(facet.Value), 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: некорректное числовое ограничение %q", models.ErrInvalidSchema, facet.Value)
+	}
+	return &value, nil
+}
+
+func facetInt(facet *xsdFacet) (*int, error) {
+	if facet == nil {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(facet.Value))
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("%w: некорректное ограничение длины %q", models.ErrInvalidSchema, facet.Value)
+	}
+	return &value, nil
+}
+
+// localName отбрасывает префикс пространства имен: xs:string -> string
+func localName(qname string) string {
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+// WithSchema включает проверку документов по XSD схеме перед конвертацией
+func WithSchema(schema *Schema) Option {
+	return func(c *Converter) {
+		c.schema = schema
+	}
+}
+
+// HasSchema сообщает, настроена ли проверка по XSD схеме
+func (c *Converter) HasSchema() bool {
+	return c.schema != nil
+}
@@ -0,0 +1,238 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const usersXSD = `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="users">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="user" type="UserType" maxOccurs="unbounded"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+
+  <xs:complexType name="UserType">
+    <xs:sequence>
+      <xs:element name="name" type="xs:string"/>
+      <xs:element name="email" type="EmailType"/>
+      <xs:element name="age" type="AgeType" minOccurs="0"/>
+      <xs:element name="phone" type="xs:string" minOccurs="0" maxOccurs="2"/>
+    </xs:sequence>
+    <xs:attribute name="id" type="xs:positiveInteger" use="required"/>
+  </xs:complexType>
+
+  <xs:simpleType name="EmailType">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[^@\s]+@[^@\s]+"/>
+    </xs:restriction>
+  </xs:simpleType>
+
+  <xs:simpleType name="AgeType">
+    <xs:restriction base="xs:integer">
+      <xs:minInclusive value="1"/>
+      <xs:maxInclusive value="110"/>
+    </xs:restriction>
+  </xs:simpleType>
+</xs:schema>`
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := ParseSchema([]byte(usersXSD))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		xml        string
+		violations []models.SchemaViolation
+	}{
+		{
+			name: "валидный документ",
+			xml: `<users>
+  <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+  <user id="2"><name>Мария</name><email>maria@example.com</email></user>
+</users>`,
+		},
+		{
+			name: "отсутствует обязательный элемент",
+			xml: `<users>
+  <user id="1"><email>ivan@example.com</email></user>
+</users>`,
+			violations: []models.SchemaViolation{
+				{Line: 2, Column: 16, Path: "/users/user[1]", Message: "отсутствует обязательный элемент <name>"},
+			},
+		},
+		{
+			name: "нарушен порядок элементов",
+			xml: `<users>
+  <user id="1"><email>ivan@example.com</email><name>Иван</name></user>
+</users>`,
+			violations: []models.SchemaViolation{
+				{Line: 2, Column: 16, Path: "/users/user[1]", Message: "отсутствует обязательный элемент <name>"},
+				{Line: 2, Column: 47, Path: "/users/user[1]/name[1]", Message: "элемент <name> нарушает порядок элементов <user>"},
+			},
+		},
+		{
+			name: "неверный тип и шаблон",
+			xml: `<users>
+  <user id="abc"><name>Иван</name><email>ivan.example.com</email><age>тридцать</age></user>
+</users>`,
+			violations: []models.SchemaViolation{
+				{Line: 2, Column: 3, Path: "/users/user[1]/@id", Message: `значение "abc" не является positiveInteger`},
+				{Line: 2, Column: 39, Path: "/users/user[1]/email[1]", Message: `значение "ivan.example.com" не соответствует шаблону типа EmailType`},
+				{Line: 2, Column: 70, Path: "/users/user[1]/age[1]", Message: `значение "тридцать" не является числом`},
+			},
+		},
+		{
+			name: "значение вне диапазона",
+			xml:  `<users><user id="1"><name>Иван</name><email>ivan@example.com</email><age>150</age></user></users>`,
+			violations: []models.SchemaViolation{
+				{Line: 1, Column: 73, Path: "/users/user[1]/age[1]", Message: "значение 150 больше 110"},
+			},
+		},
+		{
+			name: "превышен maxOccurs",
+			xml:  `<users><user id="1"><name>Иван</name><email>a@b</email><phone>1</phone><phone>2</phone><phone>3</phone></user></users>`,
+			violations: []models.SchemaViolation{
+				{Line: 1, Column: 92, Path: "/users/user[1]/phone[3]", Message: "элемент <phone> встречается больше 2 раз"},
+			},
+		},
+		{
+			name: "отсутствует атрибут и лишний элемент",
+			xml:  `<users><user><name>Иван</name><email>a@b</email><role>admin</role></user></users>`,
+			violations: []models.SchemaViolation{
+				{Line: 1, Column: 8, Path: "/users/user[1]", Message: `отсутствует обязательный атрибут "id"`},
+				{Line: 1, Column: 53, Path: "/users/user[1]/role[1]", Message: "элемент <role> не объявлен в <user>"},
+			},
+		},
+		{
+			name: "нет ни одного пользователя",
+			xml:  `<users></users>`,
+			violations: []models.SchemaViolation{
+				{Line: 1, Column: 16, Path: "/users", Message: "отсутствует обязательный элемент <user>"},
+			},
+		},
+		{
+			name: "неизвестный корневой элемент",
+			xml:  `<people><user id="1"/></people>`,
+			violations: []models.SchemaViolation{
+				{Line: 1, Column: 1, Path: "/people", Message: "корневой элемент <people> не объявлен в схеме"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(strings.NewReader(tt.xml))
+
+			if tt.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var schemaErr *models.SchemaError
+			require.ErrorAs(t, err, &schemaErr)
+			assert.ErrorIs(t, err, models.ErrSchemaValidation)
+			assert.Equal(t, tt.violations, schemaErr.Violations)
+		})
+	}
+}
+
+func TestSchema_Validate_SOAP(t *testing.T) {
+	schema, err := ParseSchema([]byte(usersXSD))
+	require.NoError(t, err)
+
+	err = schema.Validate(strings.NewReader(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+    <soap:Header><auth>secret</auth></soap:Header>
+    <soap:Body>
+        <users><user id="1"><name>Иван</name><email>ivan@example.com</email></user></users>
+    </soap:Body>
+</soap:Envelope>`))
+
+	assert.NoError(t, err)
+}
+
+func TestSchema_Validate_InvalidXML(t *testing.T) {
+	schema, err := ParseSchema([]byte(usersXSD))
+	require.NoError(t, err)
+
+	err = schema.Validate(strings.NewReader(`<users><user id="1"><name>Иван</user></users>`))
+
+	assert.ErrorIs(t, err, models.ErrInvalidXML)
+	assert.NotErrorIs(t, err, models.ErrSchemaValidation)
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		xsd  string
+	}{
+		{
+			name: "нет глобальных элементов",
+			xsd:  `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`,
+		},
+		{
+			name: "неизвестный тип",
+			xsd: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="users" type="UsersType"/>
+</xs:schema>`,
+		},
+		{
+			name: "некорректный maxOccurs",
+			xsd: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="users"><xs:complexType><xs:sequence>
+    <xs:element name="user" minOccurs="2" maxOccurs="1"/>
+  </xs:sequence></xs:complexType></xs:element>
+</xs:schema>`,
+		},
+		{
+			name: "некорректный pattern",
+			xsd: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="id"><xs:simpleType><xs:restriction base="xs:string">
+    <xs:pattern value="[a-"/>
+  </xs:restriction></xs:simpleType></xs:element>
+</xs:schema>`,
+		},
+		{
+			name: "рекурсивный тип",
+			xsd: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:element name="node" type="NodeType"/>
+  <xs:complexType name="NodeType"><xs:sequence>
+    <xs:element name="node" type="NodeType" minOccurs="0"/>
+  </xs:sequence></xs:complexType>
+</xs:schema>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema([]byte(tt.xsd))
+			assert.ErrorIs(t, err, models.ErrInvalidSchema)
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.xsd")
+	require.NoError(t, os.WriteFile(path, []byte(usersXSD), 0o644))
+
+	schema, err := LoadSchema(path)
+	require.NoError(t, err)
+
+	converter := NewConverter(WithSchema(schema))
+	assert.True(t, converter.HasSchema())
+	assert.False(t, NewConverter().HasSchema())
+
+	// Без схемы проверка ничего не делает
+	assert.NoError(t, NewConverter().ValidateSchema(strings.NewReader(`<people/>`)))
+
+	err = converter.ValidateSchema(strings.NewReader(`<users><user id="1"><name>Иван</name></user></users>`))
+	assert.ErrorIs(t, err, models.ErrSchemaValidation)
+}
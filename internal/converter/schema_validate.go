@@ -0,0 +1,368 @@
+package converter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// SchemaMaxViolations - сколько нарушений собирается, прежде чем проверка остановится
+const SchemaMaxViolations = 100
+
+// Предел длины текста простого элемента, который накапливается для проверки
+const schemaMaxText = 64 << 10
+
+// ValidateSchema проверяет документ по XSD схеме конвертера.
+// Документ читается потоково; SOAP конверт пропускается.
+// Возвращает *models.SchemaError со списком нарушений или ошибку разбора XML.
+// Если схема не настроена, всегда возвращает nil.
+func (c *Converter) ValidateSchema(r io.Reader) error {
+	if c.schema == nil {
+		return nil
+	}
+	return c.schema.validate(newXMLDecoder(r, c.charset, c.limits))
+}
+
+// Validate проверяет документ по схеме с ограничениями по умолчанию;
+// кодировка берется из BOM или объявления XML
+func (s *Schema) Validate(r io.Reader) error {
+	return s.validate(newXMLDecoder(r, "", DefaultLimits()))
+}
+
+func (s *Schema) validate(decoder *xmlDecoder) error {
+	v := &schemaValidator{schema: s, decoder: decoder}
+	if err := v.run(); err != nil {
+		return err
+	}
+	if len(v.violations) > 0 {
+		return &models.SchemaError{Violations: v.violations}
+	}
+	return nil
+}
+
+// schemaFrame - открытый элемент документа
+type schemaFrame struct {
+	element  *schemaElement
+	path     string
+	line     int
+	column   int
+	position int            // Текущая позиция в xs:sequence
+	count    int            // Сколько раз встретился элемент на текущей позиции
+	children map[string]int // Номера дочерних элементов для пути
+	text     strings.Builder
+}
+
+type schemaValidator struct {
+	schema     *Schema
+	decoder    *xmlDecoder
+	stack      []*schemaFrame
+	rootSeen   bool
+	violations []models.SchemaViolation
+}
+
+func (v *schemaValidator) run() error {
+	for len(v.violations) < SchemaMaxViolations {
+		// Позиция до чтения токена указывает на начало тега
+		line, column := v.decoder.InputPos()
+
+		token, err := v.decoder.Token()
+		if err == io.EOF {
+			if !v.rootSeen {
+				return fmt.Errorf("%w: %w", models.ErrInvalidXML, io.ErrUnexpectedEOF)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := v.start(t, line, column); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			v.end()
+		case xml.CharData:
+			v.text(t, line, column)
+		}
+	}
+	return nil
+}
+
+// start обрабатывает открывающий тег
+func (v *schemaValidator) start(t xml.StartElement, line, column int) error {
+	if len(v.stack) == 0 {
+		if v.rootSeen {
+			return nil
+		}
+		if isSOAP(t.Name) {
+			switch t.Name.Local {
+			case "Envelope", "Body":
+				// Схема описывает содержимое конверта
+				return nil
+			default:
+				return v.skip()
+			}
+		}
+
+		v.rootSeen = true
+		element, ok := v.schema.elements[t.Name.Local]
+		if !ok {
+			v.report(line, column, "/"+t.Name.Local, fmt.Sprintf("корневой элемент <%s> не объявлен в схеме", t.Name.Local))
+			return v.skip()
+		}
+		v.push(element, "/"+t.Name.Local, t, line, column)
+		return nil
+	}
+
+	parent := v.stack[len(v.stack)-1]
+	if parent.children == nil {
+		parent.children = make(map[string]int)
+	}
+	parent.children[t.Name.Local]++
+	path := fmt.Sprintf("%s/%s[%d]", parent.path, t.Name.Local, parent.children[t.Name.Local])
+
+	if parent.element.complex == nil {
+		v.report(line, column, path, fmt.Sprintf("элемент <%s> не может содержать дочерних элементов", parent.element.name))
+		return v.skip()
+	}
+
+	element := v.match(parent, t.Name.Local, path, line, column)
+	if element == nil {
+		return v.skip()
+	}
+	v.push(element, path, t, line, column)
+	return nil
+}
+
+// match находит объявление дочернего элемента в xs:sequence родителя
+// с учетом порядка и minOccurs/maxOccurs
+func (v *schemaValidator) match(parent *schemaFrame, name, path string, line, column int) *schemaElement {
+	sequence := parent.element.complex.sequence
+
+	ahead := slices.IndexFunc(sequence[parent.position:], func(e *schemaElement) bool { return e.name == name })
+	if ahead < 0 {
+		if slices.ContainsFunc(sequence, func(e *schemaElement) bool { return e.name == name }) {
+			v.report(line, column, path, fmt.Sprintf("элемент <%s> нарушает порядок элементов <%s>", name, parent.element.name))
+		} else {
+			v.report(line, column, path, fmt.Sprintf("элемент <%s> не объявлен в <%s>", name, parent.element.name))
+		}
+		return nil
+	}
+
+	if ahead > 0 {
+		// Пропущенные позиции должны быть необязательными
+		v.checkMissing(parent, parent.position, parent.position+ahead, line, column)
+		parent.position += ahead
+		parent.count = 0
+	}
+
+	element := sequence[parent.position]
+	parent.count++
+	if element.maxOccurs >= 0 && parent.count > element.maxOccurs {
+		v.report(line, column, path, fmt.Sprintf("элемент <%s> встречается больше %d раз", name, element.maxOccurs))
+	}
+	return element
+}
+
+// checkMissing сообщает об обязательных элементах в позициях [from, to) последовательности
+func (v *schemaValidator) checkMissing(frame *schemaFrame, from, to, line, column int) {
+	for i := from; i < to; i++ {
+		element := frame.element.complex.sequence[i]
+		count := 0
+		if i == frame.position {
+			count = frame.count
+		}
+		if count < element.minOccurs {
+			if element.minOccurs == 1 {
+				v.report(line, column, frame.path, fmt.Sprintf("отсутствует обязательный элемент <%s>", element.name))
+			} else {
+				v.report(line, column, frame.path, fmt.Sprintf("элемент <%s> должен встречаться не меньше %d раз", element.name, element.minOccurs))
+			}
+		}
+	}
+}
+
+// push открывает элемент и проверяет его атрибуты
+func (v *schemaValidator) push(element *schemaElement, path string, t xml.StartElement, line, column int) {
+	v.stack = append(v.stack, &schemaFrame{element: element, path: path, line: line, column: column})
+
+	var declared []*schemaAttribute
+	if element.complex != nil {
+		declared = element.complex.attributes
+	}
+
+	for _, attr := range t.Attr {
+		// Объявления пространств имен и атрибуты xsi не проверяются
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || attr.Name.Space == "http://www.w3.org/2001/XMLSchema-instance" {
+			continue
+		}
+		i := slices.IndexFunc(declared, func(a *schemaAttribute) bool { return a.name == attr.Name.Local })
+		if i < 0 {
+			v.report(line, column, path+"/@"+attr.Name.Local, fmt.Sprintf("атрибут %q не объявлен в <%s>", attr.Name.Local, element.name))
+			continue
+		}
+		if message := declared[i].simple.check(attr.Value); message != "" {
+			v.report(line, column, path+"/@"+attr.Name.Local, message)
+		}
+	}
+
+	for _, attr := range declared {
+		if !attr.required {
+			continue
+		}
+		if !slices.ContainsFunc(t.Attr, func(a xml.Attr) bool { return a.Name.Local == attr.name }) {
+			v.report(line, column, path, fmt.Sprintf("отсутствует обязательный атрибут %q", attr.name))
+		}
+	}
+}
+
+// text накапливает текст простого элемента; в сложных элементах допускаются только пробелы
+func (v *schemaValidator) text(data xml.CharData, line, column int) {
+	if len(v.stack) == 0 {
+		return
+	}
+	frame := v.stack[len(v.stack)-1]
+
+	if frame.element.complex != nil {
+		if strings.TrimSpace(string(data)) != "" {
+			v.report(line, column, frame.path, fmt.Sprintf("элемент <%s> не может содержать текст", frame.element.name))
+		}
+		return
+	}
+	if frame.text.Len()+len(data) <= schemaMaxText {
+		frame.text.Write(data)
+	}
+}
+
+// end закрывает элемент и проверяет его содержимое
+func (v *schemaValidator) end() {
+	if len(v.stack) == 0 {
+		return
+	}
+	frame := v.stack[len(v.stack)-1]
+	v.stack = v.stack[:len(v.stack)-1]
+
+	line, column := v.decoder.InputPos()
+	if frame.element.complex != nil {
+		v.checkMissing(frame, frame.position, len(frame.element.complex.sequence), line, column)
+		return
+	}
+	if message := frame.element.simple.check(frame.text.String()); message != "" {
+		v.report(frame.line, frame.column, frame.path, message)
+	}
+}
+
+// skip пропускает текущий элемент целиком
+func (v *schemaValidator) skip() error {
+	if err := v.decoder.Skip(); err != nil {
+		return fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+	}
+	return nil
+}
+
+func (v *schemaValidator) report(line, column int, path, message string) {
+	if len(v.violations) >= SchemaMaxViolations {
+		return
+	}
+	v.violations = append(v.violations, models.SchemaViolation{
+		Line:    line,
+		Column:  column,
+		Path:    path,
+		Message: message,
+	})
+}
+
+// check проверяет значение простого типа; возвращает описание нарушения или пустую строку
+func (t *schemaSimpleType) check(value string) string {
+	if t.builtin != "string" && t.builtin != "normalizedString" {
+		// Для нестроковых типов XSD схлопывает пробелы
+		value = strings.TrimSpace(value)
+	}
+
+	for ; t != nil; t = t.base {
+		if message := t.checkFacets(value); message != "" {
+			return message
+		}
+		if t.base == nil {
+			return checkBuiltin(t.builtin, value)
+		}
+	}
+	return ""
+}
+
+// checkFacets проверяет ограничения restriction одного уровня
+func (t *schemaSimpleType) checkFacets(value string) string {
+	for _, re := range t.patterns {
+		if !re.MatchString(value) {
+			return fmt.Sprintf("значение %q не соответствует шаблону типа %s", value, t.name)
+		}
+	}
+	if len(t.enumeration) > 0 && !slices.Contains(t.enumeration, value) {
+		return fmt.Sprintf("значение %q не входит в перечисление типа %s", value, t.name)
+	}
+
+	if t.minLength != nil || t.maxLength != nil {
+		length := utf8.RuneCountInString(value)
+		if t.minLength != nil && length < *t.minLength {
+			return fmt.Sprintf("длина значения %q меньше %d", value, *t.minLength)
+		}
+		if t.maxLength != nil && length > *t.maxLength {
+			return fmt.Sprintf("длина значения %q больше %d", value, *t.maxLength)
+		}
+	}
+
+	if t.minInclusive != nil || t.maxInclusive != nil {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Sprintf("значение %q не является числом", value)
+		}
+		if t.minInclusive != nil && number < *t.minInclusive {
+			return fmt.Sprintf("значение %s меньше %s", value, strconv.FormatFloat(*t.minInclusive, 'f', -1, 64))
+		}
+		if t.maxInclusive != nil && number > *t.maxInclusive {
+			return fmt.Sprintf("значение %s больше %s", value, strconv.FormatFloat(*t.maxInclusive, 'f', -1, 64))
+		}
+	}
+	return ""
+}
+
+// checkBuiltin проверяет значение встроенного типа XSD
+func checkBuiltin(builtin, value string) string {
+	valid := true
+	switch builtin {
+	case "integer", "int", "long", "short":
+		_, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil
+	case "positiveInteger":
+		n, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil && n > 0
+	case "nonNegativeInteger":
+		n, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil && n >= 0
+	case "decimal", "float", "double":
+		_, err := strconv.ParseFloat(value, 64)
+		valid = err == nil
+	case "boolean":
+		valid = value == "true" || value == "false" || value == "1" || value == "0"
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		valid = err == nil
+	case "dateTime":
+		_, err := time.Parse(time.RFC3339, value)
+		valid = err == nil
+	}
+
+	if !valid {
+		return fmt.Sprintf("значение %q не является %s", value, builtin)
+	}
+	return ""
+}
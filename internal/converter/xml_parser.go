@@ -1,8 +1,8 @@
 package converter
 
 import (
-	"encoding/xml"
-	"fmt"
+	"bytes"
+	"io"
 
 	"github.com/NarthurN/GoXML_JSON/internal/models"
 )
@@ -13,10 +13,20 @@ func (c *Converter) ParseXML(data []byte) (*models.XMLUsers, error) {
 		return nil, models.ErrEmptyData
 	}
 
+	decoder := c.NewUserDecoder(bytes.NewReader(data))
+
 	var users models.XMLUsers
-	if err := xml.Unmarshal(data, &users); err != nil {
-		return nil, fmt.Errorf("❌ ошибка при парсинге XML: %w", err)
+	for {
+		user, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		users.Users = append(users.Users, user)
 	}
+	users.XMLName = decoder.Root()
 
 	if len(users.Users) == 0 {
 		return nil, models.ErrEmptyUsers
@@ -522,3 +522,22 @@ func BenchmarkConverter_ParseXML_LargeXML(b *testing.B) {
 		}
 	}
 }
+
+func TestConverter_ParseXML_UserXMLToJSON(t *testing.T) {
+	converter := NewConverter()
+
+	result, err := converter.ParseXML([]byte(`<users>
+    <user id="1"><name>Петр Сидоров</name><email>petr@example.com</email><age> 40 </age></user>
+    <user id="2"><name>Мария Петрова</name><email>maria@example.com</email><age>30</age></user>
+</users>`))
+	require.NoError(t, err)
+	require.Len(t, result.Users, 2)
+
+	// Возраст из документа попадает в группу без валидации
+	old := converter.UserXMLToJSON(result.Users[0])
+	assert.Equal(t, AgeGroupOld, old.AgeGroup)
+	assert.Equal(t, AgeGroupCodeOld, old.AgeGroupCode)
+
+	middle := converter.UserXMLToJSON(result.Users[1])
+	assert.Equal(t, AgeGroupMiddle, middle.AgeGroup)
+}
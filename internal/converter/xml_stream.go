@@ -0,0 +1,206 @@
+package converter
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// UserDecoder - потоковый декодер пользователей из XML.
+// Читает документ токенами и отдаёт записи <user> по одной,
+// поэтому потребление памяти не зависит от размера документа.
+type UserDecoder struct {
+	decoder     *xmlDecoder
+	mapping     *Mapping
+	namespaces  map[string]struct{}
+	root        xml.Name
+	maxUsers    int  // Максимальное число записей (0 - без ограничения)
+	count       int  // Сколько записей прочитано
+	passthrough bool // Сохранять нераспознанные атрибуты и элементы записи
+	started     bool
+	done        bool
+}
+
+// NewUserDecoder создает потоковый декодер пользователей поверх r
+func (c *Converter) NewUserDecoder(r io.Reader) *UserDecoder {
+	namespaces := c.namespaces
+	if c.mapping != nil && len(c.mapping.Namespaces) > 0 {
+		namespaces = namespaceSet(c.mapping.Namespaces)
+	}
+
+	return &UserDecoder{
+		decoder:     newXMLDecoder(r, c.charset, c.limits),
+		mapping:     c.mapping,
+		namespaces:  namespaces,
+		maxUsers:    c.limits.MaxUsers,
+		passthrough: c.passthrough != PassthroughOff,
+	}
+}
+
+// Root возвращает имя корневого элемента (доступно после первого вызова Next)
+func (d *UserDecoder) Root() xml.Name {
+	return d.root
+}
+
+// Next возвращает следующего пользователя из документа.
+// Когда пользователи закончились, возвращает io.EOF.
+func (d *UserDecoder) Next() (models.XMLUser, error) {
+	if d.done {
+		return models.XMLUser{}, io.EOF
+	}
+
+	if !d.started {
+		if err := d.findRoot(); err != nil {
+			d.done = true
+			return models.XMLUser{}, err
+		}
+		d.started = true
+	}
+
+	for {
+		// Позиция до чтения токена указывает на начало тега
+		line, column := d.decoder.InputPos()
+
+		token, err := d.decoder.Token()
+		if err != nil {
+			d.done = true
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return models.XMLUser{}, fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != d.recordName() {
+				if err := d.decoder.Skip(); err != nil {
+					d.done = true
+					return models.XMLUser{}, fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+				}
+				continue
+			}
+			if err := d.checkNamespace(t.Name); err != nil {
+				d.done = true
+				return models.XMLUser{}, err
+			}
+			if d.count++; d.maxUsers > 0 && d.count > d.maxUsers {
+				d.done = true
+				return models.XMLUser{}, fmt.Errorf("%w: %w", models.ErrInvalidXML, &models.PositionError{
+					Position: models.Position{Line: line, Column: column},
+					Err:      fmt.Errorf("%w: больше %d", models.ErrTooManyUsers, d.maxUsers),
+				})
+			}
+
+			d.decoder.startRecord(line, column)
+			return d.decodeUser(&t)
+		case xml.EndElement:
+			// Закрылся корневой элемент - пользователей больше нет
+			d.done = true
+			return models.XMLUser{}, io.EOF
+		}
+	}
+}
+
+// findRoot пропускает пролог документа и находит коллекцию пользователей.
+// Коллекция может быть корневым элементом или лежать внутри SOAP конверта (Envelope/Body).
+func (d *UserDecoder) findRoot() error {
+	for {
+		token, err := d.decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == d.rootName():
+				if err := d.checkNamespace(t.Name); err != nil {
+					return err
+				}
+				d.root = t.Name
+				return nil
+			case isSOAP(t.Name) && (t.Name.Local == "Envelope" || t.Name.Local == "Body"):
+				// Спускаемся внутрь конверта
+				continue
+			case isSOAP(t.Name) && t.Name.Local == "Header":
+				if err := d.decoder.Skip(); err != nil {
+					return fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+				}
+			case isSOAP(t.Name) && t.Name.Local == "Fault":
+				return d.decodeSOAPFault(&t)
+			default:
+				return fmt.Errorf("%w: expected element type <%s> but have <%s>", models.ErrInvalidXML, d.rootName(), t.Name.Local)
+			}
+		case xml.EndElement:
+			// Конверт закрылся, а коллекция пользователей так и не встретилась
+			return fmt.Errorf("%w: element <%s> not found", models.ErrInvalidXML, d.rootName())
+		}
+	}
+}
+
+// decodeUser читает запись пользователя: напрямую в models.XMLUser
+// или, если задан маппинг, в произвольное дерево с последующим применением маппинга
+// Запись запоминает свое место в документе для отчета об ошибках.
+func (d *UserDecoder) decodeUser(start *xml.StartElement) (models.XMLUser, error) {
+	var user models.XMLUser
+	switch {
+	case d.mapping == nil && d.passthrough:
+		if err := d.decoder.DecodeElement(&user, start); err != nil {
+			return models.XMLUser{}, d.recordError(err)
+		}
+	case d.mapping == nil:
+		record := knownUser{XMLUser: &user}
+		if err := d.decoder.DecodeElement(&record, start); err != nil {
+			return models.XMLUser{}, d.recordError(err)
+		}
+	default:
+		var record models.XMLNode
+		if err := d.decoder.DecodeElement(&record, start); err != nil {
+			return models.XMLUser{}, d.recordError(err)
+		}
+		user = d.mapping.Apply(&record)
+	}
+	if user.AgeText != "" {
+		// Нечисловой возраст остается нулем и отклоняется при валидации по AgeText
+		user.Age, _ = strconv.Atoi(strings.TrimSpace(user.AgeText))
+	}
+
+	user.Position = d.decoder.recordPosition()
+	return user, nil
+}
+
+// recordError останавливает чтение из-за ошибки в записи.
+// Ошибки без места в документе привязываются к началу записи.
+func (d *UserDecoder) recordError(err error) error {
+	d.done = true
+
+	var positionErr *models.PositionError
+	if !errors.As(err, &positionErr) {
+		err = &models.PositionError{Position: d.decoder.recordPosition(), Err: err}
+	}
+	return fmt.Errorf("%w: %w", models.ErrInvalidXML, err)
+}
+
+// rootName возвращает ожидаемое имя корневого элемента
+func (d *UserDecoder) rootName() string {
+	if d.mapping != nil {
+		return d.mapping.Root
+	}
+	return "users"
+}
+
+// recordName возвращает имя элемента с записью пользователя
+func (d *UserDecoder) recordName() string {
+	if d.mapping != nil {
+		return d.mapping.Record
+	}
+	return "user"
+}
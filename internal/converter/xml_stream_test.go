@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateUsersXML потоково пишет XML с count пользователями в pipe
+func generateUsersXML(count int) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprint(pw, `<?xml version="1.0" encoding="UTF-8"?><users>`)
+		for i := 1; i <= count; i++ {
+			fmt.Fprintf(pw, `<user id="%d"><name>Пользователь %d</name><email>user%d@example.com</email><age>%d</age></user>`,
+				i, i, i, 20+(i%50))
+		}
+		fmt.Fprint(pw, `</users>`)
+		pw.Close()
+	}()
+	return pr
+}
+
+func TestUserDecoder_Next(t *testing.T) {
+	converter := NewConverter()
+
+	decoder := converter.NewUserDecoder(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<users>
+    <meta><source>HR</source></meta>
+    <user id="1">
+        <name>Иван Иванов</name>
+        <email>ivan@example.com</email>
+        <age>30</age>
+    </user>
+    <user id="2">
+        <name>Мария Петрова</name>
+        <email>maria@example.com</email>
+        <age>25</age>
+    </user>
+</users>`))
+
+	user, err := decoder.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "users", decoder.Root().Local)
+	assert.Equal(t, models.XMLUser{
+		ID:       "1",
+		Name:     "Иван Иванов",
+		Email:    "ivan@example.com",
+		Age:      30,
+		AgeText:  "30",
+		Position: models.Position{Line: 4, Column: 5, Snippet: `<user id="1">`},
+	}, user)
+
+	user, err = decoder.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "2", user.ID)
+
+	_, err = decoder.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// Повторный вызов после окончания также возвращает io.EOF
+	_, err = decoder.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestUserDecoder_Next_Errors(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		name    string
+		xmlData string
+	}{
+		{
+			name:    "неправильный корневой элемент",
+			xmlData: `<root><user id="1"><name>Иван</name></user></root>`,
+		},
+		{
+			name:    "не XML данные",
+			xmlData: `Это не XML данные`,
+		},
+		{
+			name:    "обрезанный документ",
+			xmlData: `<users><user id="1"><name>Иван</name>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := converter.NewUserDecoder(strings.NewReader(tt.xmlData))
+
+			var err error
+			for err == nil {
+				_, err = decoder.Next()
+			}
+
+			assert.ErrorIs(t, err, models.ErrInvalidXML)
+		})
+	}
+}
+
+func TestConverter_UsersXMLStreamToJSON(t *testing.T) {
+	converter := NewConverter()
+
+	result, err := converter.UsersXMLStreamToJSON(generateUsersXML(5000))
+
+	require.NoError(t, err)
+	require.Len(t, result, 5000)
+
+	// Порядок пользователей сохраняется несмотря на параллельную обработку
+	for i, jsonUser := range result {
+		assert.Equal(t, fmt.Sprintf("%d", i+1), jsonUser.ID)
+		assert.Equal(t, fmt.Sprintf("Пользователь %d", i+1), jsonUser.FullName)
+	}
+}
+
+func TestConverter_UsersXMLStreamToJSON_Errors(t *testing.T) {
+	converter := NewConverter()
+
+	t.Run("часть пользователей невалидна", func(t *testing.T) {
+		result, err := converter.UsersXMLStreamToJSON(strings.NewReader(`<users>
+    <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+    <user id="2"><name></name><email>maria@example.com</email><age>25</age></user>
+</users>`))
+
+		assert.ErrorIs(t, err, models.ErrEmptyName)
+		require.Len(t, result, 1)
+		assert.Equal(t, "1", result[0].ID)
+	})
+
+	t.Run("нечисловой возраст", func(t *testing.T) {
+		result, err := converter.UsersXMLStreamToJSON(strings.NewReader(`<users>
+    <user id="1"><name>Иван</name><email>ivan@example.com</email><age>тридцать</age></user>
+    <user id="2"><name>Мария</name><email>maria@example.com</email><age> 25 </age></user>
+</users>`))
+
+		// Ошибка относится к записи, а не ко всему документу
+		assert.NotErrorIs(t, err, models.ErrInvalidXML)
+		assert.ErrorIs(t, err, models.ErrInvalidAge)
+		report := ValidationReport(err)
+		require.Len(t, report, 1)
+		assert.Equal(t, 0, report[0].Index)
+		assert.Equal(t, models.CodeInvalidAge, report[0].Code)
+		assert.NotContains(t, err.Error(), "strconv")
+		require.Len(t, result, 1)
+		assert.Equal(t, "2", result[0].ID)
+	})
+
+	t.Run("нет пользователей", func(t *testing.T) {
+		result, err := converter.UsersXMLStreamToJSON(strings.NewReader(`<users></users>`))
+
+		assert.ErrorIs(t, err, models.ErrEmptyUsers)
+		assert.Nil(t, result)
+	})
+
+	t.Run("синтаксическая ошибка после валидных записей", func(t *testing.T) {
+		result, err := converter.UsersXMLStreamToJSON(strings.NewReader(`<users>
+    <user id="1"><name>Иван</name><email>ivan@example.com</email><age>30</age></user>
+    <user id="2"><name>Мария</email></user>
+</users>`))
+
+		assert.ErrorIs(t, err, models.ErrInvalidXML)
+		assert.Nil(t, result)
+	})
+}
+
+// Benchmark тест для потоковой обработки большого документа
+func BenchmarkConverter_UsersXMLStreamToJSON(b *testing.B) {
+	converter := NewConverter()
+
+	for i := 0; i < b.N; i++ {
+		_, err := converter.UsersXMLStreamToJSON(generateUsersXML(1000))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// usersXML возвращает документ с count пользователями целиком, чтобы генерация не попадала в замеры
+func usersXML(count int) []byte {
+	document, err := io.ReadAll(generateUsersXML(count))
+	if err != nil {
+		panic(err)
+	}
+	return document
+}
+
+// Память конвейерного режима не должна расти с документом: на запись приходится
+// одинаковое число аллокаций, сколько бы записей ни было в документе
+func TestConverter_ConvertStream_AllocsPerRecord(t *testing.T) {
+	converter := NewConverter()
+	allocsPerRecord := func(count int) float64 {
+		document := usersXML(count)
+		allocs := testing.AllocsPerRun(5, func() {
+			records, err := converter.ConvertStream(bytes.NewReader(document), func(models.JSONUser) error { return nil })
+			if err != nil || records != count {
+				t.Fatalf("records = %d, err = %v", records, err)
+			}
+		})
+		return allocs / float64(count)
+	}
+
+	small := allocsPerRecord(1000)
+	large := allocsPerRecord(20000)
+
+	t.Logf("аллокаций на запись: %.2f (1000 записей), %.2f (20000 записей)", small, large)
+	assert.InDelta(t, small, large, small*0.05, "число аллокаций на запись растет с документом")
+}
+
+// Benchmark конвейерного режима на двух размерах документа: allocs/op, деленные на число записей,
+// должны совпадать
+func BenchmarkConverter_ConvertStream(b *testing.B) {
+	converter := NewConverter()
+	for _, count := range []int{1000, 10000} {
+		document := usersXML(count)
+		b.Run(fmt.Sprintf("%d записей", count), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(document)))
+			for i := 0; i < b.N; i++ {
+				if _, err := converter.ConvertStream(bytes.NewReader(document), func(models.JSONUser) error { return nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+)
+
+// AgeRangeAttr - атрибут записи с диапазоном возрастов, восстановленным по возрастной группе
+const AgeRangeAttr = "age_range"
+
+// XMLOutput - формат XML документа, который строит UsersJSONToXML
+type XMLOutput struct {
+	Root    string // Корневой элемент
+	Element string // Элемент записи пользователя
+	Indent  string // Отступ вложенных элементов ("" - документ в одну строку)
+}
+
+// DefaultXMLOutput возвращает формат входных документов: <users><user id="...">...</user></users>
+func DefaultXMLOutput() XMLOutput {
+	return XMLOutput{Root: "users", Element: "user", Indent: "  "}
+}
+
+// Validate проверяет имена элементов и отступ
+func (o XMLOutput) Validate() error {
+	for _, name := range []string{o.Root, o.Element} {
+		if !isXMLName(name) {
+			return fmt.Errorf("%w: некорректное имя элемента %q", models.ErrInvalidXMLOutput, name)
+		}
+	}
+	if strings.Trim(o.Indent, " \t") != "" {
+		return fmt.Errorf("%w: отступ может состоять только из пробелов и табуляций", models.ErrInvalidXMLOutput)
+	}
+	return nil
+}
+
+// WithXMLOutput задает формат документов обратного преобразования
+func WithXMLOutput(output XMLOutput) Option {
+	return func(c *Converter) {
+		c.xmlOutput = output
+	}
+}
+
+// XMLOutput возвращает формат документов обратного преобразования
+func (c *Converter) XMLOutput() XMLOutput {
+	return c.xmlOutput
+}
+
+// ForXMLOutput возвращает копию конвертера, строящую документы в формате output
+func (c *Converter) ForXMLOutput(output XMLOutput) (*Converter, error) {
+	if err := output.Validate(); err != nil {
+		return nil, err
+	}
+
+	scoped := *c
+	scoped.xmlOutput = output
+	return &scoped, nil
+}
+
+// UsersJSONToXML - обратное преобразование: строит XML документ из пользователей в формате JSON,
+// чтобы вернуть исправленные данные в систему-источник. Возрастная группа (по коду или названию
+// на любом языке текущей схемы) превращается в атрибут age_range: "25-35", "0-24", "36+".
+// Если хоть одна запись не прошла проверку, документ не строится; ошибки записей
+// объединяются через errors.Join и извлекаются через ValidationReport.
+func (c *Converter) UsersJSONToXML(users []models.JSONUser) ([]byte, error) {
+	ranges := make([]string, len(users))
+	var errs []error
+	for i, user := range users {
+		ageRange, err := c.reverseUser(user)
+		if err != nil {
+			errs = append(errs, c.validationError(i, user.ID, err))
+			continue
+		}
+		ranges[i] = ageRange
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", c.xmlOutput.Indent)
+
+	root := xml.StartElement{Name: xml.Name{Local: c.xmlOutput.Root}}
+	tokens := []xml.Token{root}
+	for i, user := range users {
+		start := xml.StartElement{
+			Name: xml.Name{Local: c.xmlOutput.Element},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: user.ID}},
+		}
+		if ranges[i] != "" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: AgeRangeAttr}, Value: ranges[i]})
+		}
+		tokens = append(tokens, start)
+		tokens = appendTextElement(tokens, "name", user.FullName)
+		tokens = appendTextElement(tokens, "email", user.Email)
+		tokens = append(tokens, start.End())
+	}
+	tokens = append(tokens, root.End())
+
+	for _, token := range tokens {
+		if err := encoder.EncodeToken(token); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// reverseUser проверяет пользователя и возвращает диапазон возрастов его группы
+func (c *Converter) reverseUser(user models.JSONUser) (string, error) {
+	if strings.TrimSpace(user.ID) == "" {
+		return "", models.ErrEmptyID
+	}
+	return c.ageRange(user)
+}
+
+// ageRange ищет группу пользователя в текущей схеме: сначала по коду, затем по названию.
+// Пустая группа дает пустой диапазон - атрибут age_range не выводится.
+func (c *Converter) ageRange(user models.JSONUser) (string, error) {
+	if user.AgeGroupCode == "" && user.AgeGroup == "" {
+		return "", nil
+	}
+
+	for _, ageRange := range c.ageGroups.Ranges {
+		if user.AgeGroupCode != "" {
+			if ageRange.Code == user.AgeGroupCode {
+				return formatAgeRange(ageRange), nil
+			}
+			continue
+		}
+		for _, lang := range c.labels.Languages() {
+			if c.labels.Label(lang, ageRange) == user.AgeGroup {
+				return formatAgeRange(ageRange), nil
+			}
+		}
+	}
+
+	group := user.AgeGroupCode
+	if group == "" {
+		group = user.AgeGroup
+	}
+	return "", fmt.Errorf("%w: %q в схеме %s", models.ErrUnknownAgeGroup, group, c.AgeScheme())
+}
+
+// formatAgeRange записывает включительные границы диапазона: "25-35", "0-24", "36+"
+func formatAgeRange(ageRange AgeRange) string {
+	low, high := ageRange.bounds()
+	low = max(low, 0)
+	switch {
+	case high == math.MaxInt:
+		return strconv.Itoa(low) + "+"
+	case low == high:
+		return strconv.Itoa(low)
+	default:
+		return strconv.Itoa(low) + "-" + strconv.Itoa(high)
+	}
+}
+
+// appendTextElement добавляет токены элемента <name>text</name>
+func appendTextElement(tokens []xml.Token, name, text string) []xml.Token {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	return append(tokens, start, xml.CharData(text), start.End())
+}
+
+// isXMLName сообщает, что name - имя XML элемента без префикса пространства имен
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return !strings.HasPrefix(strings.ToLower(name), "xml")
+}
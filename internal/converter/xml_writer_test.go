@@ -0,0 +1,150 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter_UsersJSONToXML(t *testing.T) {
+	users := []models.JSONUser{
+		{ID: "1", FullName: "Иван Иванов", Email: "ivan@example.com", AgeGroup: AgeGroupMiddle},
+		{ID: "2", FullName: "Мария & Co", Email: "maria@example.com", AgeGroup: "under 25"},
+		{ID: "3", FullName: "Петр", Email: "petr@example.com", AgeGroup: "что угодно", AgeGroupCode: AgeGroupCodeOld},
+		{ID: "4", FullName: "Анна", Email: "anna@example.com"},
+	}
+
+	data, err := NewConverter().UsersJSONToXML(users)
+
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<users>
+  <user id="1" age_range="25-35">
+    <name>Иван Иванов</name>
+    <email>ivan@example.com</email>
+  </user>
+  <user id="2" age_range="0-24">
+    <name>Мария &amp; Co</name>
+    <email>maria@example.com</email>
+  </user>
+  <user id="3" age_range="36+">
+    <name>Петр</name>
+    <email>petr@example.com</email>
+  </user>
+  <user id="4">
+    <name>Анна</name>
+    <email>anna@example.com</email>
+  </user>
+</users>`, string(data))
+}
+
+func TestConverter_UsersJSONToXML_Output(t *testing.T) {
+	conv, err := NewConverter().ForXMLOutput(XMLOutput{Root: "employees", Element: "employee"})
+	require.NoError(t, err)
+
+	data, err := conv.UsersJSONToXML([]models.JSONUser{
+		{ID: "1", FullName: "Иван", Email: "ivan@example.com", AgeGroupCode: AgeGroupCodeYang},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<employees><employee id="1" age_range="0-24"><name>Иван</name><email>ivan@example.com</email></employee></employees>`, string(data))
+	assert.Equal(t, DefaultXMLOutput(), NewConverter().XMLOutput(), "исходный конвертер не меняется")
+}
+
+func TestConverter_UsersJSONToXML_RoundTrip(t *testing.T) {
+	// Атрибут age_range не входит в модель и сохраняется при чтении только с переносом
+	conv := NewConverter(WithPassthrough(PassthroughFlatten))
+	users := []models.JSONUser{
+		{ID: "1", FullName: "Иван Иванов", Email: "ivan@example.com", AgeGroup: AgeGroupMiddle},
+		{ID: "2", FullName: "Мария", Email: "maria@example.com", AgeGroup: AgeGroupYang},
+	}
+
+	data, err := conv.UsersJSONToXML(users)
+	require.NoError(t, err)
+
+	parsed, err := conv.ParseXML(data)
+	require.NoError(t, err)
+	require.Len(t, parsed.Users, 2)
+	assert.Equal(t, "users", parsed.XMLName.Local)
+	assert.Equal(t, "Иван Иванов", parsed.Users[0].Name)
+	assert.Equal(t, "maria@example.com", parsed.Users[1].Email)
+	assert.Equal(t, "25-35", parsed.Users[0].UnknownAttrs[0].Value)
+}
+
+func TestConverter_UsersJSONToXML_AgeScheme(t *testing.T) {
+	schemes, err := LoadAgeGroupSchemes("../../age_groups.json")
+	require.NoError(t, err)
+	conv, err := NewConverter(WithAgeGroupSchemes(schemes)).ForAgeScheme("marketing")
+	require.NoError(t, err)
+
+	data, err := conv.UsersJSONToXML([]models.JSONUser{
+		{ID: "1", AgeGroup: "18-29"},
+		{ID: "2", AgeGroupCode: "ge60"},
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<user id="1" age_range="18-29">`)
+	assert.Contains(t, string(data), `<user id="2" age_range="60+">`)
+}
+
+func TestConverter_UsersJSONToXML_InvalidUsers(t *testing.T) {
+	conv := NewConverter().ForLanguage("en")
+
+	data, err := conv.UsersJSONToXML([]models.JSONUser{
+		{ID: "1", FullName: "Иван", AgeGroup: AgeGroupMiddle},
+		{ID: " ", FullName: "Мария", AgeGroup: AgeGroupYang},
+		{ID: "3", FullName: "Петр", AgeGroup: "старше 100"},
+	})
+
+	assert.Nil(t, data)
+	assert.ErrorIs(t, err, models.ErrEmptyID)
+	assert.ErrorIs(t, err, models.ErrUnknownAgeGroup)
+
+	report := ValidationReport(err)
+	require.Len(t, report, 2)
+	assert.Equal(t, 1, report[0].Index)
+	assert.Equal(t, models.CodeEmptyID, report[0].Code)
+	assert.Equal(t, 2, report[1].Index)
+	assert.Equal(t, "age_group", report[1].Field)
+	assert.Equal(t, "unknown age group", report[1].Message)
+}
+
+func TestXMLOutput_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  XMLOutput
+		wantErr bool
+	}{
+		{name: "по умолчанию", output: DefaultXMLOutput()},
+		{name: "кириллица и дефис", output: XMLOutput{Root: "сотрудники", Element: "user-record", Indent: "\t"}},
+		{name: "пустой корень", output: XMLOutput{Element: "user"}, wantErr: true},
+		{name: "имя с цифры", output: XMLOutput{Root: "1users", Element: "user"}, wantErr: true},
+		{name: "префикс пространства имен", output: XMLOutput{Root: "ns:users", Element: "user"}, wantErr: true},
+		{name: "зарезервированное имя", output: XMLOutput{Root: "xmlUsers", Element: "user"}, wantErr: true},
+		{name: "отступ не из пробелов", output: XMLOutput{Root: "users", Element: "user", Indent: "--"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.output.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, models.ErrInvalidXMLOutput)
+				_, scopedErr := NewConverter().ForXMLOutput(tt.output)
+				assert.Error(t, scopedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFormatAgeRange(t *testing.T) {
+	ranges := DefaultAgeGroupRules().Ranges
+	assert.Equal(t, "0-24", formatAgeRange(ranges[0]))
+	assert.Equal(t, "25-35", formatAgeRange(ranges[1]))
+	assert.Equal(t, "36+", formatAgeRange(ranges[2]))
+	assert.Equal(t, "7", formatAgeRange(AgeRange{Min: intPtr(7), Max: intPtr(7), Code: "7", Label: "7"}))
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/NarthurN/GoXML_JSON/internal/client"
+)
+
+// Health - обработчик для GET запроса на /health.
+// Сервер отвечает 200, пока работает сам; неисправность сервера-получателя
+// видна по состоянию предохранителя и статусу "degraded".
+func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	breaker := h.client.BreakerState()
+
+	status := "ok"
+	if breaker.State != client.BreakerClosed {
+		status = "degraded"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{
+		"status":   status,
+		"upstream": breaker,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Logf("❌ Health: ошибка при отправке ответа: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthResponse - ответ GET /health
+type healthResponse struct {
+	Status   string              `json:"status"`
+	Upstream client.BreakerState `json:"upstream"`
+}
+
+// getHealth запрашивает состояние сервиса
+func getHealth(t *testing.T, h *Handler) healthResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	// Сервис работает, поэтому статус ответа 200 при любом состоянии предохранителя
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var response healthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	return response
+}
+
+func TestHandler_Health_Breaker(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	accept := acceptAll(t)
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "недоступен", http.StatusServiceUnavailable)
+			return
+		}
+		accept(w, r)
+	}, client.WithBreaker(client.BreakerSettings{Window: 2, MinRequests: 2, FailureRate: 1, CoolDown: 100 * time.Millisecond, HalfOpenRequests: 1}))
+
+	health := getHealth(t, h)
+	assert.Equal(t, "ok", health.Status)
+	assert.Equal(t, client.BreakerClosed, health.Upstream.State)
+	assert.Nil(t, health.Upstream.OpenUntil)
+
+	// Первая ошибка видна в окне, но предохранитель еще замкнут
+	postUsers(http.HandlerFunc(h.Users), "/users", "application/xml", usersXML)
+	health = getHealth(t, h)
+	assert.Equal(t, "ok", health.Status)
+	assert.Equal(t, 1, health.Upstream.Requests)
+	assert.Equal(t, 1, health.Upstream.Failures)
+
+	// Вторая ошибка размыкает предохранитель
+	postUsers(http.HandlerFunc(h.Users), "/users", "application/xml", usersXML)
+	health = getHealth(t, h)
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, client.BreakerOpen, health.Upstream.State)
+	require.NotNil(t, health.Upstream.OpenUntil)
+	assert.True(t, health.Upstream.OpenUntil.After(time.Now()))
+
+	// После паузы пропускается пробная попытка
+	time.Sleep(150 * time.Millisecond)
+	health = getHealth(t, h)
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, client.BreakerHalfOpen, health.Upstream.State)
+
+	// Успешная пробная попытка замыкает предохранитель
+	failing.Store(false)
+	rec := postUsers(http.HandlerFunc(h.Users), "/users", "application/xml", usersXML)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	health = getHealth(t, h)
+	assert.Equal(t, "ok", health.Status)
+	assert.Equal(t, client.BreakerClosed, health.Upstream.State)
+}
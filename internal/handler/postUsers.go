@@ -1,53 +1,136 @@
 package handler
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/client"
+	"github.com/NarthurN/GoXML_JSON/internal/converter"
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // Users - обработчик для POST запроса на /users
 func (h *Handler) Users(w http.ResponseWriter, r *http.Request) {
-	h.logger.Log("🙏 Users: начало обработки запроса")
+	h.logger.Logf("🙏 Users: начало обработки запроса, request_id=%s", middleware.GetReqID(r.Context()))
 
-	// Чтение тела запроса
-	defer r.Body.Close()
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		h.logger.Logf("❌ Users: ошибка при чтении тела запроса: %v", err)
-		http.Error(w, "Ошибка при чтении тела запроса", http.StatusBadRequest)
+	// Пока предохранитель разомкнут, отвечаем сразу, не читая документ
+	if state := h.client.BreakerState(); state.State == client.BreakerOpen {
+		err := &client.OpenError{RetryAfter: time.Until(*state.OpenUntil)}
+		h.logger.Logf("❌ Users: %v", err)
+		h.upstreamError(w, r, err, nil)
 		return
 	}
 
-	if len(body) == 0 {
-		h.logger.Log("❌ Users: тело запроса пустое")
-		http.Error(w, "Тело запроса пустое", http.StatusBadRequest)
+	// Схема возрастных групп выбирается для каждого запроса отдельно
+	conv, err := h.converter.ForAgeScheme(ageSchemeFromRequest(r))
+	if err != nil {
+		h.logger.Logf("❌ Users: %v", err)
+		h.problem(w, r, problem.BadRequest("Неизвестная схема возрастных групп: "+ageSchemeFromRequest(r)))
+		return
+	}
+	// Формат входного XML определяется маппингом, выбранным в запросе
+	conv, err = conv.ForMapping(mappingFromRequest(r))
+	if err != nil {
+		h.logger.Logf("❌ Users: %v", err)
+		h.problem(w, r, problem.BadRequest("Неизвестный маппинг полей: "+mappingFromRequest(r)))
 		return
 	}
 
-	// TODO: убрать, если не тестируется
-	h.logger.Logf("✅ Users: тело запроса успешно прочитано, размер: %d байт", len(body))
-	// h.logger.Logf("✅ Users: тело запроса успешно прочитано, размер: %d байт", len(body))
+	// Политика обработки повторяющихся ID может быть задана в запросе
+	conv, err = conv.ForDuplicatePolicy(duplicatePolicyFromRequest(r))
+	if err != nil {
+		h.logger.Logf("❌ Users: %v", err)
+		h.problem(w, r, problem.BadRequest("Неизвестная политика обработки дубликатов: "+duplicatePolicyFromRequest(r)))
+		return
+	}
 
-	// Парсинг XML
-	users, err := h.converter.ParseXML(body)
+	// Кодировка из Content-Type важнее объявления <?xml encoding="..."?>
+	conv, err = conv.ForCharset(charsetFromRequest(r))
 	if err != nil {
+		h.logger.Logf("❌ Users: %v", err)
+		h.problem(w, r, problem.UnsupportedMediaType("Неподдерживаемая кодировка: "+charsetFromRequest(r)))
+		return
+	}
+
+	// Язык названий групп берется из Accept-Language, иначе из настроек
+	if lang := conv.MatchLanguage(r.Header.Get("Accept-Language")); lang != "" {
+		conv = conv.ForLanguage(lang)
+	}
+	w.Header().Set("Content-Language", conv.Language())
+	h.logger.Logf("✅ Users: схема возрастных групп: %s, язык: %s, маппинг: %q, дубликаты: %s, кодировка: %q", conv.AgeScheme(), conv.Language(), conv.Mapping(), conv.DuplicatePolicy(), conv.Charset())
+
+	stream := r.URL.Query().Get("mode") == "stream"
+	if stream && conv.DuplicatePolicy() != converter.DuplicateKeepFirst {
+		h.logger.Logf("❌ Users: политика дубликатов %s недоступна в потоковом режиме", conv.DuplicatePolicy())
+		h.problem(w, r, problem.BadRequest("В режиме stream доступна только политика дубликатов "+converter.DuplicateKeepFirst))
+		return
+	}
+
+	// Тело запроса читается потоково, без загрузки в память целиком
+	defer r.Body.Close()
+	body := bufio.NewReader(r.Body)
+	if _, err := body.Peek(1); err != nil {
+		if err == io.EOF {
+			h.logger.Log("❌ Users: тело запроса пустое")
+			h.problem(w, r, problem.BadRequest("Тело запроса пустое"))
+			return
+		}
+		h.logger.Logf("❌ Users: ошибка при чтении тела запроса: %v", err)
+		h.problem(w, r, bodyProblem(err))
+		return
+	}
+
+	// Если настроена XSD схема, документ проверяется целиком до конвертации
+	var input io.Reader = body
+	if conv.HasSchema() {
+		document, ok := h.validateSchema(w, r, conv, body)
+		if !ok {
+			return
+		}
+		defer closeSpool(document)
+		input = document
+	}
+
+	if stream {
+		h.usersPipelined(w, r, conv, input)
+		return
+	}
+
+	// Потоково парсим XML и асинхронно обрабатываем записи Users из XML в JSON
+	jsonUsers, err := conv.UsersXMLStreamToJSON(input)
+	if errors.Is(err, models.ErrInvalidXML) || errors.Is(err, models.ErrEmptyUsers) {
 		h.logger.Logf("❌ Users: ошибка при парсинге XML: %v", err)
-		http.Error(w, "Ошибка при парсинге XML", http.StatusBadRequest)
+		h.problem(w, r, xmlProblem(err))
 		return
 	}
 
-	h.logger.Logf("✅ Users: XML успешно пропарсен: %v", users)
+	h.logger.Logf("✅ Users: XML успешно пропарсен, валидных пользователей: %d", len(jsonUsers))
+
+	// Построчный отчет о записях, не прошедших валидацию, о повторяющихся ID и предупреждения
+	report := converter.ValidationReport(err)
+	duplicates := converter.DuplicateReport(err)
+	nameWarnings := converter.WarningReport(err)
 
-	// Асинхронно обрабатываем записи Users из XML в JSON
-	jsonUsers, err := h.converter.UsersXMLToJSON(users)
 	if len(jsonUsers) == 0 {
 		if err != nil {
 			h.logger.Logf("❌ Нет валидных пользователей для отправки. Ошибки: %v", err)
 		} else {
 			h.logger.Log("❌ Входной файл не содержал валидных пользователей.")
 		}
-		http.Error(w, "Не найдено валидных пользователей в предоставленных данных.", http.StatusBadRequest)
+		h.problem(w, r, problem.ValidationFailed(validationErrors(report)).WithDuplicates(duplicates))
 		return
 	}
 
@@ -57,28 +140,344 @@ func (h *Handler) Users(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Logf("✅ Сконвертировано %d валидных пользователей. Начинаем отправку...", len(jsonUsers))
 
-	// Отправляем JSON пользователей на localhost:8080/users
+	// Отправляем JSON пользователей на сервер-получатель пакетами
 	h.logger.Log("🙏 Users: Отправляем пользователей на сервер")
-	bodyResp, err := h.client.SendUsers(r.Context(), jsonUsers)
+	delivery, err := h.client.SendUsersBatches(r.Context(), jsonUsers)
+	if err == nil && delivery.Delivered+delivery.Refused == 0 {
+		err = delivery.Err()
+	}
 	if err != nil {
 		h.logger.Logf("❌ Users: ошибка при отправке JSON пользователей: %v", err)
-		http.Error(w, "Ошибка при отправке JSON пользователей", http.StatusInternalServerError)
+		h.upstreamError(w, r, err, nil)
 		return
 	}
-	h.logger.Log("✅ Пользователи успешно отправлены на сервер")
+
+	// Часть пакетов могла не дойти, а часть пользователей - быть отклонена сервером:
+	// тогда ответ 207, пакеты с ошибками в delivery, отклоненные пользователи в rejected
+	status := http.StatusOK
+	if delivery.Failed > 0 || delivery.Refused > 0 {
+		status = http.StatusMultiStatus
+		h.logger.Logf("⚠️ Users: принято %d пользователей, отклонено сервером %d, не отправлено %d: %v", delivery.Delivered, delivery.Refused, delivery.Failed, delivery.Err())
+	} else {
+		h.logger.Logf("✅ Пользователи успешно отправлены на сервер: %d в %d пакетах", delivery.Delivered, len(delivery.Batches))
+	}
+
+	// Итог по каждому пользователю с номером записи в документе
+	perUser := delivery.Report(jsonUsers)
 
 	// Формируем ответ
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
+
+	response := map[string]interface{}{
+		"data":             batchResponses(delivery),
+		"delivery":         delivery,
+		"usersProcessed":   delivery.Delivered,
+		"delivered":        perUser.Delivered,
+		"rejected":         perUser.Rejected,
+		"failed":           perUser.Failed,
+		"ageScheme":        conv.AgeScheme(),
+		"validationErrors": validationErrors(report),
+		"duplicates":       duplicateErrors(duplicates),
+		"warnings":         warnings(nameWarnings),
+	}
+
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Logf("❌ Users: ошибка при отправке ответа: %v", err)
+		return
+	}
+}
+
+// usersPipelined - конвейерный режим обработки (POST /users?mode=stream).
+// Пользователи конвертируются и сразу пишутся в тело запроса к серверу или в очередной пакет,
+// поэтому сервис пересылает файлы любого размера с постоянным расходом памяти.
+func (h *Handler) usersPipelined(w http.ResponseWriter, r *http.Request, conv *converter.Converter, body io.Reader) {
+	h.logger.Log("🙏 Users: конвейерная обработка, отправляем пользователей на сервер по мере конвертации")
+
+	var (
+		processed       int
+		records         int // Прочитано записей <user>, включая невалидные
+		validationError error
+	)
+	delivery, err := h.client.SendUsersStream(r.Context(), func(emit func(models.JSONUser) error) error {
+		var (
+			emitErr error
+			err     error
+		)
+		records, err = conv.ConvertStream(body, func(user models.JSONUser) error {
+			if emitErr = emit(user); emitErr != nil {
+				return emitErr
+			}
+			processed++
+			return nil
+		})
+		if emitErr != nil || errors.Is(err, models.ErrInvalidXML) {
+			return err
+		}
+		// Ошибки валидации не прерывают отправку: невалидные записи пропускаются
+		validationError = err
+		return nil
+	})
+
+	if validationError != nil {
+		h.logger.Logf("⚠️ Часть пользователей не прошла валидацию и была пропущена. Ошибки: %v", validationError)
+	}
+	// Ни один пользователь не отправлен: все пакеты с ошибкой
+	if err == nil && delivery.Delivered+delivery.Refused == 0 {
+		err = delivery.Err()
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidXML):
+		h.logger.Logf("❌ Users: ошибка при парсинге XML: %v", err)
+		h.problem(w, r, xmlProblem(err).WithDelivery(sentBatches(delivery)))
+		return
+	case errors.Is(err, models.ErrNoUsers) && records == 0:
+		// Документ без записей - та же ошибка, что и в обычном режиме
+		h.logger.Logf("❌ Users: ошибка при парсинге XML: %v", models.ErrEmptyUsers)
+		h.problem(w, r, xmlProblem(models.ErrEmptyUsers))
+		return
+	case errors.Is(err, models.ErrNoUsers):
+		h.logger.Log("❌ Нет валидных пользователей для отправки.")
+		h.problem(w, r, problem.ValidationFailed(validationErrors(converter.ValidationReport(validationError))).
+			WithDuplicates(converter.DuplicateReport(validationError)))
+		return
+	case err != nil:
+		h.logger.Logf("❌ Users: ошибка при отправке JSON пользователей: %v", err)
+		h.upstreamError(w, r, err, sentBatches(delivery))
+		return
+	}
+
+	status := http.StatusOK
+	if delivery.Refused > 0 || delivery.Failed > 0 {
+		status = http.StatusMultiStatus
+		h.logger.Logf("⚠️ Users: принято %d пользователей, отклонено сервером %d, не отправлено %d", delivery.Delivered, delivery.Refused, delivery.Failed)
+	} else {
+		h.logger.Logf("✅ Пользователи успешно отправлены на сервер: %d в %d пакетах", processed, len(delivery.Batches))
+	}
+
+	// Формируем ответ. Принятые пользователи не перечисляются: список рос бы с размером документа
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 
 	response := map[string]interface{}{
-		"data":           json.RawMessage(bodyResp),
-		"usersProcessed": len(jsonUsers),
+		"data":             batchResponses(delivery.Delivery),
+		"delivery":         delivery.Delivery,
+		"usersProcessed":   delivery.Delivered,
+		"rejected":         delivery.Rejected,
+		"ageScheme":        conv.AgeScheme(),
+		"validationErrors": validationErrors(converter.ValidationReport(validationError)),
+		"duplicates":       duplicateErrors(converter.DuplicateReport(validationError)),
+		"warnings":         warnings(converter.WarningReport(validationError)),
 	}
 
 	if err = json.NewEncoder(w).Encode(response); err != nil {
 		h.logger.Logf("❌ Users: ошибка при отправке ответа: %v", err)
-		http.Error(w, "Ошибка при отправке ответа", http.StatusInternalServerError)
 		return
 	}
 }
+
+// problem отправляет клиенту ошибку в формате application/problem+json
+func (h *Handler) problem(w http.ResponseWriter, r *http.Request, p *problem.Problem) {
+	if err := problem.Write(w, r, p); err != nil {
+		h.logger.Logf("❌ Users: ошибка при отправке ответа: %v", err)
+	}
+}
+
+// batchResponses возвращает ответ сервера-получателя: для одного пакета - как есть,
+// для нескольких - массив ответов по пакетам (null для пакетов с ошибкой)
+func batchResponses(delivery *client.Delivery) any {
+	if len(delivery.Batches) == 1 {
+		return delivery.Batches[0].Response
+	}
+	responses := make([]*models.UpstreamResponse, len(delivery.Batches))
+	for i, batch := range delivery.Batches {
+		responses[i] = batch.Response
+	}
+	return responses
+}
+
+// upstreamError отвечает об ошибке отправки пользователей на сервер-получатель.
+// При разомкнутом предохранителе в Retry-After передается остаток паузы.
+// delivery - пакеты, отправленные до ошибки (nil - ни одного).
+func (h *Handler) upstreamError(w http.ResponseWriter, r *http.Request, err error, delivery any) {
+	var openErr *client.OpenError
+	if errors.As(err, &openErr) && openErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(openErr.RetryAfter.Seconds()))))
+	}
+	h.problem(w, r, upstreamProblem(err).WithDelivery(delivery))
+}
+
+// sentBatches возвращает пакеты, отправленные в потоковом режиме до ошибки, для ответа о ней;
+// nil, если ни один пакет не отправлялся
+func sentBatches(delivery *client.StreamDelivery) any {
+	if delivery == nil || len(delivery.Batches) == 0 {
+		return nil
+	}
+	return delivery.Delivery
+}
+
+// upstreamProblem описывает ошибку отправки пользователей на сервер-получатель
+func upstreamProblem(err error) *problem.Problem {
+	switch {
+	case errors.Is(err, models.ErrCircuitOpen):
+		return problem.UpstreamUnavailable("Сервер-получатель неисправен, отправка временно приостановлена")
+	case errors.Is(err, context.DeadlineExceeded):
+		return problem.Timeout("Сервер-получатель не ответил вовремя")
+	case errors.Is(err, models.ErrInvalidUpstreamResponse):
+		return problem.UpstreamFailed("Сервер-получатель вернул некорректный ответ")
+	}
+	return problem.UpstreamFailed("Сервер-получатель недоступен или ответил ошибкой")
+}
+
+// xmlProblem описывает ошибку разбора XML; в detail попадает причина без общего префикса.
+// Превышение размера тела или числа записей - 413, остальные ошибки - 400.
+func xmlProblem(err error) *problem.Problem {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return bodyProblem(tooLarge)
+	}
+
+	// В конвейерном режиме ошибка разбора обернута ошибкой клиента
+	detail := err.Error()
+	if _, cause, ok := strings.Cut(detail, models.ErrInvalidXML.Error()+": "); ok {
+		detail = cause
+	}
+	detail = strings.TrimPrefix(detail, "❌ ")
+
+	p := problem.InvalidXML(detail)
+	if errors.Is(err, models.ErrTooManyUsers) {
+		p = problem.PayloadTooLarge(detail)
+	}
+	// Место ошибки в документе, чтобы найти ее в большом файле
+	var positionErr *models.PositionError
+	if errors.As(err, &positionErr) {
+		p.WithPosition(positionErr.Line, positionErr.Column, positionErr.Snippet)
+	}
+	return p
+}
+
+// bodyProblem описывает ошибку чтения тела запроса
+func bodyProblem(err error) *problem.Problem {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return problem.PayloadTooLarge(fmt.Sprintf("Тело запроса больше %d байт", tooLarge.Limit))
+	}
+	return problem.BadRequest("Ошибка при чтении тела запроса")
+}
+
+// validationErrors возвращает отчет для ответа: пустой массив вместо null
+func validationErrors(report []models.ValidationError) []models.ValidationError {
+	if report == nil {
+		return []models.ValidationError{}
+	}
+	return report
+}
+
+// duplicateErrors возвращает описания дубликатов для ответа: пустой массив вместо null
+func duplicateErrors(duplicates []models.DuplicateError) []models.DuplicateError {
+	if duplicates == nil {
+		return []models.DuplicateError{}
+	}
+	return duplicates
+}
+
+// warnings возвращает предупреждения о принятых записях для ответа: пустой массив вместо null
+func warnings(report []models.Warning) []models.Warning {
+	if report == nil {
+		return []models.Warning{}
+	}
+	return report
+}
+
+// validateSchema проверяет документ по XSD схеме.
+// Тело запроса сохраняется во временный файл, чтобы после проверки прочитать его повторно
+// без загрузки в память. При нарушениях отвечает 400 со списком нарушений и возвращает false.
+func (h *Handler) validateSchema(w http.ResponseWriter, r *http.Request, conv *converter.Converter, body io.Reader) (*os.File, bool) {
+	document, err := os.CreateTemp("", "users-*.xml")
+	if err != nil {
+		h.logger.Logf("❌ Users: ошибка при создании временного файла: %v", err)
+		h.problem(w, r, problem.Internal("Ошибка при чтении тела запроса"))
+		return nil, false
+	}
+	if _, err := io.Copy(document, body); err != nil {
+		closeSpool(document)
+		h.logger.Logf("❌ Users: ошибка при чтении тела запроса: %v", err)
+		h.problem(w, r, bodyProblem(err))
+		return nil, false
+	}
+	if _, err := document.Seek(0, io.SeekStart); err != nil {
+		closeSpool(document)
+		h.logger.Logf("❌ Users: ошибка при чтении временного файла: %v", err)
+		h.problem(w, r, problem.Internal("Ошибка при чтении тела запроса"))
+		return nil, false
+	}
+
+	err = conv.ValidateSchema(bufio.NewReader(document))
+
+	var schemaErr *models.SchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		closeSpool(document)
+		h.logger.Logf("❌ Users: %v", err)
+		h.problem(w, r, problem.SchemaViolation(schemaErr.Violations))
+		return nil, false
+	case err != nil:
+		closeSpool(document)
+		h.logger.Logf("❌ Users: ошибка при парсинге XML: %v", err)
+		h.problem(w, r, xmlProblem(err))
+		return nil, false
+	}
+
+	if _, err := document.Seek(0, io.SeekStart); err != nil {
+		closeSpool(document)
+		h.logger.Logf("❌ Users: ошибка при чтении временного файла: %v", err)
+		h.problem(w, r, problem.Internal("Ошибка при чтении тела запроса"))
+		return nil, false
+	}
+	h.logger.Log("✅ Users: документ соответствует XSD схеме")
+	return document, true
+}
+
+// closeSpool закрывает и удаляет временный файл с телом запроса
+func closeSpool(document *os.File) {
+	document.Close()
+	os.Remove(document.Name())
+}
+
+// ageSchemeFromRequest возвращает схему возрастных групп из параметра age_scheme
+// или заголовка X-Age-Scheme; пустая строка означает схему по умолчанию
+func ageSchemeFromRequest(r *http.Request) string {
+	if scheme := r.URL.Query().Get("age_scheme"); scheme != "" {
+		return scheme
+	}
+	return r.Header.Get("X-Age-Scheme")
+}
+
+// mappingFromRequest возвращает маппинг полей из параметра mapping
+// или заголовка X-Mapping; пустая строка означает стандартный формат
+func mappingFromRequest(r *http.Request) string {
+	if mapping := r.URL.Query().Get("mapping"); mapping != "" {
+		return mapping
+	}
+	return r.Header.Get("X-Mapping")
+}
+
+// duplicatePolicyFromRequest возвращает политику обработки дубликатов из параметра duplicates
+// или заголовка X-Duplicate-Policy; пустая строка означает политику из настроек
+func duplicatePolicyFromRequest(r *http.Request) string {
+	if policy := r.URL.Query().Get("duplicates"); policy != "" {
+		return policy
+	}
+	return r.Header.Get("X-Duplicate-Policy")
+}
+
+// charsetFromRequest возвращает кодировку из параметра charset заголовка Content-Type;
+// пустая строка означает кодировку из BOM или объявления XML
+func charsetFromRequest(r *http.Request) string {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/NarthurN/GoXML_JSON/internal/converter"
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxIndent - наибольший отступ выходного XML, пробелов
+const maxIndent = 8
+
+// UsersXML - обработчик для POST запроса на /users/xml.
+// Обратное преобразование: JSON массив пользователей превращается в XML документ
+// для выгрузки исправленных данных в систему-источник.
+func (h *Handler) UsersXML(w http.ResponseWriter, r *http.Request) {
+	h.logger.Logf("🙏 UsersXML: начало обработки запроса, request_id=%s", middleware.GetReqID(r.Context()))
+
+	// Схема возрастных групп нужна, чтобы восстановить диапазон возрастов по группе
+	conv, err := h.converter.ForAgeScheme(ageSchemeFromRequest(r))
+	if err != nil {
+		h.logger.Logf("❌ UsersXML: %v", err)
+		h.problem(w, r, problem.BadRequest("Неизвестная схема возрастных групп: "+ageSchemeFromRequest(r)))
+		return
+	}
+
+	output, err := xmlOutputFromRequest(r, conv.XMLOutput())
+	if err == nil {
+		conv, err = conv.ForXMLOutput(output)
+	}
+	if err != nil {
+		h.logger.Logf("❌ UsersXML: %v", err)
+		h.problem(w, r, problem.BadRequest("Некорректный формат выходного XML: "+strings.TrimPrefix(err.Error(), models.ErrInvalidXMLOutput.Error()+": ")))
+		return
+	}
+
+	// Язык сообщений об ошибках берется из Accept-Language, иначе из настроек
+	if lang := conv.MatchLanguage(r.Header.Get("Accept-Language")); lang != "" {
+		conv = conv.ForLanguage(lang)
+	}
+	h.logger.Logf("✅ UsersXML: схема возрастных групп: %s, язык: %s, корень: %s, запись: %s", conv.AgeScheme(), conv.Language(), output.Root, output.Element)
+
+	defer r.Body.Close()
+	var users []models.JSONUser
+	if err := json.NewDecoder(r.Body).Decode(&users); err != nil {
+		h.logger.Logf("❌ UsersXML: ошибка при чтении JSON: %v", err)
+		h.problem(w, r, jsonProblem(err))
+		return
+	}
+
+	data, err := conv.UsersJSONToXML(users)
+	if err != nil {
+		h.logger.Logf("❌ UsersXML: записи не прошли проверку: %v", err)
+		p := problem.InvalidRecords(validationErrors(converter.ValidationReport(err)))
+		p.Detail = "Документ не построен: исправьте записи из errors"
+		h.problem(w, r, p)
+		return
+	}
+	h.logger.Logf("✅ UsersXML: построен XML документ, пользователей: %d", len(users))
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		h.logger.Logf("❌ UsersXML: ошибка при отправке ответа: %v", err)
+	}
+}
+
+// jsonProblem описывает ошибку чтения JSON из тела запроса
+func jsonProblem(err error) *problem.Problem {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return bodyProblem(tooLarge)
+	case errors.Is(err, io.EOF):
+		return problem.BadRequest("Тело запроса пустое")
+	default:
+		return problem.BadRequest("Некорректный JSON: ожидается массив пользователей: " + err.Error())
+	}
+}
+
+// xmlOutputFromRequest возвращает формат выходного XML из параметров root, element и indent
+// (число пробелов отступа, 0 - документ в одну строку); отсутствующие параметры берутся из base
+func xmlOutputFromRequest(r *http.Request, base converter.XMLOutput) (converter.XMLOutput, error) {
+	query := r.URL.Query()
+	if root := query.Get("root"); root != "" {
+		base.Root = root
+	}
+	if element := query.Get("element"); element != "" {
+		base.Element = element
+	}
+	if indent := query.Get("indent"); indent != "" {
+		spaces, err := strconv.Atoi(indent)
+		if err != nil || spaces < 0 || spaces > maxIndent {
+			return base, fmt.Errorf("%w: отступ должен быть числом от 0 до %d", models.ErrInvalidXMLOutput, maxIndent)
+		}
+		base.Indent = strings.Repeat(" ", spaces)
+	}
+	return base, nil
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NarthurN/GoXML_JSON/internal/converter"
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonUsers - пользователи в формате, который сервис отправляет получателю
+const jsonUsers = `[
+	{"id": "1", "full_name": "Иван Иванов", "email": "ivan@example.com", "age_group_code": "25_35"},
+	{"id": "2", "full_name": "Мария Петрова", "email": "maria@example.com", "age_group": "старше 35"}
+]`
+
+// postUsersXML отправляет JSON body на обработчик обратного преобразования и возвращает ответ
+func postUsersXML(target, body string) *httptest.ResponseRecorder {
+	h := NewHandler(testLogger, converter.NewConverter(), nil)
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.UsersXML(rec, req)
+	return rec
+}
+
+func TestHandler_UsersXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{
+			name:   "формат по умолчанию",
+			target: "/users/xml",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<users>
+  <user id="1" age_range="25-35">
+    <name>Иван Иванов</name>
+    <email>ivan@example.com</email>
+  </user>
+  <user id="2" age_range="36+">
+    <name>Мария Петрова</name>
+    <email>maria@example.com</email>
+  </user>
+</users>`,
+		},
+		{
+			name:     "имена элементов и документ в одну строку",
+			target:   "/users/xml?root=employees&element=employee&indent=0",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<employees><employee id="1" age_range="25-35"><name>Иван Иванов</name><email>ivan@example.com</email></employee><employee id="2" age_range="36+"><name>Мария Петрова</name><email>maria@example.com</email></employee></employees>`,
+		},
+		{
+			name:   "отступ 4 пробела",
+			target: "/users/xml?indent=4",
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<users>
+    <user id="1" age_range="25-35">
+        <name>Иван Иванов</name>
+        <email>ivan@example.com</email>
+    </user>
+    <user id="2" age_range="36+">
+        <name>Мария Петрова</name>
+        <email>maria@example.com</email>
+    </user>
+</users>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postUsersXML(tt.target, jsonUsers)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, "application/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestHandler_UsersXML_BadRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		expected string
+	}{
+		{name: "отступ не число", target: "/users/xml?indent=два", body: jsonUsers, expected: "отступ должен быть числом от 0 до 8"},
+		{name: "отступ больше 8", target: "/users/xml?indent=9", body: jsonUsers, expected: "отступ должен быть числом от 0 до 8"},
+		{name: "отрицательный отступ", target: "/users/xml?indent=-1", body: jsonUsers, expected: "отступ должен быть числом от 0 до 8"},
+		{name: "некорректное имя элемента", target: "/users/xml?element=1user", body: jsonUsers, expected: `некорректное имя элемента "1user"`},
+		{name: "неизвестная схема", target: "/users/xml?age_scheme=unknown", body: jsonUsers, expected: "Неизвестная схема возрастных групп: unknown"},
+		{name: "некорректный JSON", target: "/users/xml", body: `[{"id": "1",`, expected: "Некорректный JSON"},
+		{name: "объект вместо массива", target: "/users/xml", body: `{"id": "1"}`, expected: "Некорректный JSON: ожидается массив пользователей"},
+		{name: "пустое тело", target: "/users/xml", body: ``, expected: "Тело запроса пустое"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postUsersXML(tt.target, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, problem.TypeBadRequest, p.Type)
+			assert.Contains(t, p.Detail, tt.expected)
+		})
+	}
+}
+
+func TestHandler_UsersXML_InvalidRecords(t *testing.T) {
+	rec := postUsersXML("/users/xml", `[
+		{"id": "1", "full_name": "Иван Иванов", "email": "ivan@example.com", "age_group": "пенсионеры"},
+		{"id": "2", "full_name": "Мария Петрова", "email": "maria@example.com", "age_group_code": "25_35"},
+		{"id": " ", "full_name": "Петр Сидоров", "email": "petr@example.com"}
+	]`)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var p struct {
+		problem.Problem
+		Errors []models.ValidationError `json:"errors"`
+	}
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+
+	assert.Equal(t, problem.TypeValidationFailed, p.Type)
+	assert.Equal(t, "Записи пользователей не прошли проверку", p.Title, "заголовок не говорит об XML")
+	require.Len(t, p.Errors, 2)
+	assert.Equal(t, 0, p.Errors[0].Index)
+	assert.Equal(t, models.CodeUnknownAgeGroup, p.Errors[0].Code)
+	assert.Equal(t, 2, p.Errors[1].Index)
+	assert.Equal(t, models.CodeEmptyID, p.Errors[1].Code)
+}
@@ -0,0 +1,605 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/client"
+	"github.com/NarthurN/GoXML_JSON/internal/converter"
+	appMiddleware "github.com/NarthurN/GoXML_JSON/internal/middleware"
+	"github.com/NarthurN/GoXML_JSON/internal/models"
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
+	"github.com/NarthurN/GoXML_JSON/pkg/logger"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger пишет в provider.log во временном каталоге тестов
+var testLogger *logger.Logger
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handler-test-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+	if testLogger, err = logger.New(); err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+
+	testLogger.Close()
+	os.Chdir(wd)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// usersXML - документ из четырех записей: запись 1 без email не проходит валидацию
+const usersXML = `<users>
+	<user id="1"><name>Иван Иванов</name><email>ivan@example.com</email><age>30</age></user>
+	<user id="2"><name>Петр Петров</name><age>40</age></user>
+	<user id="3"><name>Мария Петрова</name><email>maria@example.com</email><age>25</age></user>
+	<user id="4"><name>Анна Сидорова</name><email>anna@example.com</email><age>50</age></user>
+</users>`
+
+// newTestHandler возвращает обработчик, отправляющий пользователей на upstream.
+// По умолчанию клиент не повторяет запросы и не размыкает предохранитель.
+func newTestHandler(t *testing.T, upstream http.HandlerFunc, opts ...client.Option) *Handler {
+	return newConverterHandler(t, converter.NewConverter(), upstream, opts...)
+}
+
+// newConverterHandler - newTestHandler с конвертером conv
+func newConverterHandler(t *testing.T, conv *converter.Converter, upstream http.HandlerFunc, opts ...client.Option) *Handler {
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	opts = append([]client.Option{
+		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 1}),
+		client.WithBreaker(client.BreakerSettings{Window: 100, MinRequests: 100, FailureRate: 1, CoolDown: time.Minute}),
+	}, opts...)
+	c := client.NewClient(testLogger, opts...)
+	c.URL = server.URL
+
+	return NewHandler(testLogger, conv, c)
+}
+
+// acceptAll - сервер-получатель, принимающий всех пользователей
+func acceptAll(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(users))
+	}
+}
+
+// rejectID - сервер-получатель, отклоняющий пользователя с ID id ответом 207 с results
+func rejectID(t *testing.T, id string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var users []models.JSONUser
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&users))
+
+		response := models.UpstreamResponse{}
+		for i, user := range users {
+			result := models.UserResult{Index: i, ID: user.ID, Status: models.UserStatusAccepted}
+			if user.ID == id {
+				result.Status, result.Reason = models.UserStatusRejected, "ID уже занят"
+			}
+			response.Results = append(response.Results, result)
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}
+}
+
+// postUsers отправляет документ body на обработчик h и возвращает ответ
+func postUsers(h http.Handler, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeProblem разбирает ответ об ошибке
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) problem.Problem {
+	t.Helper()
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	var p problem.Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	return p
+}
+
+func TestHandler_Users_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		contentType  string
+		body         string
+		expectedCode int
+		expectedType string
+	}{
+		{
+			name:         "некорректный XML",
+			target:       "/users",
+			contentType:  "application/xml",
+			body:         "<users>\n<user id=\"1\"><name>Иван</user>\n</users>",
+			expectedCode: http.StatusBadRequest,
+			expectedType: problem.TypeInvalidXML,
+		},
+		{
+			name:         "пустой документ",
+			target:       "/users",
+			contentType:  "application/xml",
+			body:         "<users></users>",
+			expectedCode: http.StatusBadRequest,
+			expectedType: problem.TypeInvalidXML,
+		},
+		{
+			name:         "пустой документ в потоковом режиме",
+			target:       "/users?mode=stream",
+			contentType:  "application/xml",
+			body:         "<users></users>",
+			expectedCode: http.StatusBadRequest,
+			expectedType: problem.TypeInvalidXML,
+		},
+		{
+			name:         "неподдерживаемая кодировка",
+			target:       "/users",
+			contentType:  "application/xml; charset=x-unknown",
+			body:         usersXML,
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedType: problem.TypeUnsupportedMedia,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+			})
+
+			rec := postUsers(http.HandlerFunc(h.Users), tt.target, tt.contentType, tt.body)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, tt.expectedType, p.Type)
+			assert.Equal(t, tt.expectedCode, p.Status)
+			assert.Zero(t, calls.Load(), "сервер-получатель не вызывается")
+		})
+	}
+}
+
+func TestHandler_Users_InvalidXMLPosition(t *testing.T) {
+	for _, target := range []string{"/users", "/users?mode=stream"} {
+		t.Run(target, func(t *testing.T) {
+			h := newTestHandler(t, acceptAll(t))
+
+			rec := postUsers(http.HandlerFunc(h.Users), target, "application/xml", "<users>\n<user id=\"1\"><name>Иван</user>\n</users>")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, 2, p.Line, "место ошибки в документе")
+			assert.NotEmpty(t, p.Snippet)
+		})
+	}
+}
+
+func TestHandler_Users_PayloadTooLarge(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength bool
+	}{
+		{name: "по Content-Length", contentLength: true},
+		{name: "при чтении тела", contentLength: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+			})
+			limited := appMiddleware.MaxBodyBytes(testLogger, 64, 0)(http.HandlerFunc(h.Users))
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(usersXML))
+			req.Header.Set("Content-Type", "application/xml")
+			if !tt.contentLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			limited.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, problem.TypePayloadTooLarge, p.Type)
+			assert.Contains(t, p.Detail, "64 байт")
+			assert.Zero(t, calls.Load())
+		})
+	}
+}
+
+func TestHandler_Users_OK(t *testing.T) {
+	for _, target := range []string{"/users", "/users?mode=stream"} {
+		t.Run(target, func(t *testing.T) {
+			h := newTestHandler(t, acceptAll(t))
+
+			rec := postUsers(http.HandlerFunc(h.Users), target, "application/xml", usersXML)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			var response struct {
+				UsersProcessed   int                      `json:"usersProcessed"`
+				ValidationErrors []models.ValidationError `json:"validationErrors"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, 3, response.UsersProcessed)
+			assert.Len(t, response.ValidationErrors, 1, "невалидная запись пропущена")
+		})
+	}
+}
+
+func TestHandler_Users_MultiStatusReport(t *testing.T) {
+	t.Run("пакеты", func(t *testing.T) {
+		h := newTestHandler(t, rejectID(t, "3"), client.WithBatchPolicy(client.BatchPolicy{MaxUsers: 2}))
+
+		rec := postUsers(http.HandlerFunc(h.Users), "/users", "application/xml", usersXML)
+
+		require.Equal(t, http.StatusMultiStatus, rec.Code)
+		var response struct {
+			UsersProcessed   int                      `json:"usersProcessed"`
+			Delivered        []models.DeliveredUser   `json:"delivered"`
+			Rejected         []models.RejectedUser    `json:"rejected"`
+			Failed           []models.RejectedUser    `json:"failed"`
+			ValidationErrors []models.ValidationError `json:"validationErrors"`
+			Delivery         client.Delivery          `json:"delivery"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		assert.Equal(t, 2, response.UsersProcessed)
+		assert.Equal(t, []models.DeliveredUser{{Index: 0, ID: "1"}, {Index: 3, ID: "4"}}, response.Delivered)
+		assert.Equal(t, []models.RejectedUser{{Index: 2, ID: "3", Reason: "ID уже занят"}}, response.Rejected)
+		assert.Empty(t, response.Failed)
+		require.Len(t, response.ValidationErrors, 1)
+		assert.Equal(t, 1, response.ValidationErrors[0].Index)
+		assert.Len(t, response.Delivery.Batches, 2)
+		assert.Equal(t, 1, response.Delivery.Refused)
+	})
+
+	t.Run("конвейерный режим", func(t *testing.T) {
+		h := newTestHandler(t, rejectID(t, "3"), client.WithBatchPolicy(client.BatchPolicy{MaxUsers: 2}))
+
+		rec := postUsers(http.HandlerFunc(h.Users), "/users?mode=stream", "application/xml", usersXML)
+
+		require.Equal(t, http.StatusMultiStatus, rec.Code)
+		var response map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+
+		assert.NotContains(t, response, "delivered", "принятые пользователи только подсчитываются")
+		assert.JSONEq(t, `2`, string(response["usersProcessed"]))
+		assert.JSONEq(t, `[{"index": 2, "id": "3", "reason": "ID уже занят"}]`, string(response["rejected"]))
+
+		var delivery client.Delivery
+		require.NoError(t, json.Unmarshal(response["delivery"], &delivery))
+		assert.Len(t, delivery.Batches, 2)
+		assert.Equal(t, 1, delivery.Refused)
+	})
+}
+
+func TestHandler_Users_FailedBatch(t *testing.T) {
+	// Второй пакет сервер-получатель не принимает
+	failSecond := func(t *testing.T) http.HandlerFunc {
+		var calls atomic.Int32
+		accept := acceptAll(t)
+		return func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 2 {
+				http.Error(w, "ошибка", http.StatusInternalServerError)
+				return
+			}
+			accept(w, r)
+		}
+	}
+
+	t.Run("пакеты", func(t *testing.T) {
+		h := newTestHandler(t, failSecond(t), client.WithBatchPolicy(client.BatchPolicy{MaxUsers: 2}))
+
+		rec := postUsers(http.HandlerFunc(h.Users), "/users", "application/xml", usersXML)
+
+		require.Equal(t, http.StatusMultiStatus, rec.Code)
+		var response struct {
+			Failed []models.RejectedUser `json:"failed"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		require.Len(t, response.Failed, 1)
+		assert.Equal(t, 3, response.Failed[0].Index)
+	})
+
+	t.Run("конвейерный режим", func(t *testing.T) {
+		h := newTestHandler(t, failSecond(t), client.WithBatchPolicy(client.BatchPolicy{MaxUsers: 2}))
+
+		rec := postUsers(http.HandlerFunc(h.Users), "/users?mode=stream", "application/xml", usersXML)
+
+		require.Equal(t, http.StatusBadGateway, rec.Code)
+		var response struct {
+			Type     string          `json:"type"`
+			Delivery client.Delivery `json:"delivery"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Equal(t, problem.TypeUpstreamFailed, response.Type)
+		require.Len(t, response.Delivery.Batches, 2, "в ответе об ошибке - уже отправленные пакеты")
+		assert.Equal(t, 2, response.Delivery.Delivered)
+		assert.NotEmpty(t, response.Delivery.Batches[1].Error)
+	})
+}
+
+func TestHandler_Users_Timeout(t *testing.T) {
+	for _, target := range []string{"/users", "/users?mode=stream"} {
+		t.Run(target, func(t *testing.T) {
+			h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				// Сервер-получатель отвечает позже дедлайна запроса
+				select {
+				case <-r.Context().Done():
+				case <-time.After(2 * time.Second):
+				}
+			})
+			// У конвейерного режима свой таймаут; здесь он задан, чтобы проверить 504
+			limited := appMiddleware.Timeout(testLogger, 100*time.Millisecond, 100*time.Millisecond)(http.HandlerFunc(h.Users))
+
+			rec := postUsers(limited, target, "application/xml", usersXML)
+
+			assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, problem.TypeTimeout, p.Type)
+		})
+	}
+}
+
+func TestHandler_Users_StreamOutlivesTimeout(t *testing.T) {
+	// Клиент передает документ дольше таймаута обычного запроса (здесь 100 мс вместо ClientTimeout)
+	slowBody := func() io.Reader {
+		pr, pw := io.Pipe()
+		go func() {
+			fmt.Fprint(pw, "<users>")
+			for i := 1; i <= 6; i++ {
+				time.Sleep(50 * time.Millisecond)
+				fmt.Fprintf(pw, `<user id="%d"><name>Пользователь %d</name><email>user%d@example.com</email><age>30</age></user>`, i, i, i)
+			}
+			fmt.Fprint(pw, "</users>")
+			pw.Close()
+		}()
+		return pr
+	}
+
+	tests := []struct {
+		name string
+		opts []client.Option
+	}{
+		{name: "один запрос"},
+		{name: "пакеты", opts: []client.Option{client.WithBatchPolicy(client.BatchPolicy{MaxUsers: 2})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, acceptAll(t), tt.opts...)
+			limited := appMiddleware.Timeout(testLogger, 100*time.Millisecond, 0)(http.HandlerFunc(h.Users))
+
+			req := httptest.NewRequest(http.MethodPost, "/users?mode=stream", slowBody())
+			req.Header.Set("Content-Type", "application/xml")
+			rec := httptest.NewRecorder()
+			started := time.Now()
+			limited.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Greater(t, time.Since(started), 100*time.Millisecond, "запрос длился дольше таймаута")
+			var response struct {
+				UsersProcessed int `json:"usersProcessed"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, 6, response.UsersProcessed)
+		})
+	}
+}
+
+func TestHandler_Users_BreakerOpen(t *testing.T) {
+	var calls atomic.Int32
+	h := newTestHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "недоступен", http.StatusServiceUnavailable)
+	}, client.WithBreaker(client.BreakerSettings{Window: 1, MinRequests: 1, FailureRate: 1, CoolDown: time.Minute, HalfOpenRequests: 1}))
+
+	// Первая ошибка размыкает предохранитель
+	rec := postUsers(http.HandlerFunc(h.Users), "/users", "application/xml", usersXML)
+	require.Equal(t, http.StatusBadGateway, rec.Code)
+	require.Equal(t, int32(1), calls.Load())
+
+	for _, target := range []string{"/users", "/users?mode=stream"} {
+		t.Run(target, func(t *testing.T) {
+			// Тело не читается: отказ приходит раньше разбора документа
+			rec := postUsers(http.HandlerFunc(h.Users), target, "application/xml", "<users>")
+
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+			assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+			p := decodeProblem(t, rec)
+			assert.Equal(t, problem.TypeUpstreamUnavailable, p.Type)
+			assert.Equal(t, int32(1), calls.Load(), "сервер-получатель не вызывается")
+		})
+	}
+}
+
+func TestHandler_Users_RequestID(t *testing.T) {
+	h := newTestHandler(t, acceptAll(t))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("<users></users>"))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+	rec := httptest.NewRecorder()
+	h.Users(rec, req)
+
+	p := decodeProblem(t, rec)
+	assert.Equal(t, "req-1", p.RequestID)
+	assert.Equal(t, "/users", p.Instance)
+}
+
+// recordUsers - сервер-получатель, принимающий всех пользователей и запоминающий последний пакет в users
+func recordUsers(t *testing.T, users *[]models.JSONUser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(users))
+		fmt.Fprintf(w, `{"status": "success", "user_count": %d}`, len(*users))
+	}
+}
+
+// marketingConverter возвращает конвертер со схемой по умолчанию и схемой marketing
+func marketingConverter(t *testing.T) *converter.Converter {
+	marketing, err := converter.NewAgeGroupRules([]converter.AgeRange{
+		{Max: intPtr(30), MaxExclusive: true, Code: "lt30", Label: "до 30"},
+		{Min: intPtr(30), Code: "ge30", Label: "30+"},
+	})
+	require.NoError(t, err)
+	marketing.Name = "marketing"
+
+	schemes := converter.DefaultAgeGroupSchemes()
+	schemes.Schemes["marketing"] = marketing
+	return converter.NewConverter(converter.WithAgeGroupSchemes(schemes))
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestHandler_Users_AgeScheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		header         string
+		expectedScheme string
+		expectedGroup  string
+		expectedCode   string
+	}{
+		{name: "схема по умолчанию", target: "/users", expectedScheme: "default", expectedGroup: "от 25 до 35", expectedCode: "25_35"},
+		{name: "параметр запроса", target: "/users?age_scheme=marketing", expectedScheme: "marketing", expectedGroup: "30+", expectedCode: "ge30"},
+		{name: "заголовок X-Age-Scheme", target: "/users", header: "marketing", expectedScheme: "marketing", expectedGroup: "30+", expectedCode: "ge30"},
+		{name: "параметр важнее заголовка", target: "/users?age_scheme=default", header: "marketing", expectedScheme: "default", expectedGroup: "от 25 до 35", expectedCode: "25_35"},
+		{name: "конвейерный режим", target: "/users?mode=stream&age_scheme=marketing", expectedScheme: "marketing", expectedGroup: "30+", expectedCode: "ge30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []models.JSONUser
+			h := newConverterHandler(t, marketingConverter(t), recordUsers(t, &sent))
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(usersXML))
+			req.Header.Set("Content-Type", "application/xml")
+			if tt.header != "" {
+				req.Header.Set("X-Age-Scheme", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.Users(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var response struct {
+				AgeScheme string `json:"ageScheme"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, tt.expectedScheme, response.AgeScheme, "схема записана в ответ")
+
+			// Пользователь 1 - 30 лет
+			require.NotEmpty(t, sent)
+			assert.Equal(t, "1", sent[0].ID)
+			assert.Equal(t, tt.expectedGroup, sent[0].AgeGroup)
+			assert.Equal(t, tt.expectedCode, sent[0].AgeGroupCode)
+		})
+	}
+}
+
+func TestHandler_Users_UnknownAgeScheme(t *testing.T) {
+	for _, target := range []string{"/users?age_scheme=unknown", "/users?mode=stream&age_scheme=unknown"} {
+		t.Run(target, func(t *testing.T) {
+			var calls atomic.Int32
+			h := newConverterHandler(t, marketingConverter(t), func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+			})
+
+			rec := postUsers(http.HandlerFunc(h.Users), target, "application/xml", usersXML)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, problem.TypeBadRequest, p.Type)
+			assert.Equal(t, "Неизвестная схема возрастных групп: unknown", p.Detail)
+			assert.Zero(t, calls.Load(), "сервер-получатель не вызывается")
+		})
+	}
+}
+
+func TestHandler_Users_AcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name             string
+		target           string
+		acceptLanguage   string
+		expectedLanguage string
+		expectedGroup    string
+	}{
+		{name: "без заголовка", target: "/users", expectedLanguage: "ru", expectedGroup: "от 25 до 35"},
+		{name: "английский", target: "/users", acceptLanguage: "en", expectedLanguage: "en", expectedGroup: "25 to 35"},
+		{name: "казахский с регионом", target: "/users", acceptLanguage: "kk-KZ", expectedLanguage: "kk", expectedGroup: "25-тен 35-ке дейін"},
+		{name: "по весу q", target: "/users", acceptLanguage: "ru;q=0.5, en;q=0.9", expectedLanguage: "en", expectedGroup: "25 to 35"},
+		{name: "неподдерживаемый язык", target: "/users", acceptLanguage: "fr, de;q=0.8", expectedLanguage: "ru", expectedGroup: "от 25 до 35"},
+		{name: "первый поддерживаемый", target: "/users", acceptLanguage: "fr, en;q=0.8", expectedLanguage: "en", expectedGroup: "25 to 35"},
+		{name: "конвейерный режим", target: "/users?mode=stream", acceptLanguage: "en", expectedLanguage: "en", expectedGroup: "25 to 35"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []models.JSONUser
+			h := newTestHandler(t, recordUsers(t, &sent))
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(usersXML))
+			req.Header.Set("Content-Type", "application/xml")
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			h.Users(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.expectedLanguage, rec.Header().Get("Content-Language"))
+
+			// Пользователь 1 - 30 лет; код группы не зависит от языка
+			require.NotEmpty(t, sent)
+			assert.Equal(t, tt.expectedGroup, sent[0].AgeGroup)
+			assert.Equal(t, converter.AgeGroupCodeMiddle, sent[0].AgeGroupCode)
+		})
+	}
+}
+
+func TestHandler_Users_ContentLanguageOnError(t *testing.T) {
+	h := newTestHandler(t, acceptAll(t))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`<users>
+	<user id="1"><name>Иван Иванов</name><age>30</age></user>
+</users>`))
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	h.Users(rec, req)
+
+	// Язык выбран до разбора документа, поэтому заголовок есть и в ответе об ошибке
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	assert.Equal(t, problem.TypeValidationFailed, decodeProblem(t, rec).Type)
+}
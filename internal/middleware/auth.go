@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
 	"github.com/NarthurN/GoXML_JSON/pkg/logger"
 	"github.com/NarthurN/GoXML_JSON/settings"
 )
@@ -19,7 +20,7 @@ func Auth(logger *logger.Logger) func(http.Handler) http.Handler {
 			if !strings.HasPrefix(authHeader, prefix) {
 				logger.Logf("❌ неверный формат токена: remote=%s, path=%s, got_header=%q", r.RemoteAddr, r.URL.Path, authHeader)
 				w.Header().Set("WWW-Authenticate", `Bearer realm="Access to the API"`)
-				http.Error(w, "❌ не авторизованный доступ", http.StatusUnauthorized)
+				problem.Write(w, r, problem.Unauthorized("Ожидается заголовок Authorization: Bearer <токен>"))
 				return
 			}
 
@@ -28,7 +29,7 @@ func Auth(logger *logger.Logger) func(http.Handler) http.Handler {
 			if token != settings.AuthKey {
 				logger.Logf("❌ неверный токен: remote=%s, path=%s, got_token=%q", r.RemoteAddr, r.URL.Path, token)
 				w.Header().Set("WWW-Authenticate", `Bearer realm="Access to the API"`)
-				http.Error(w, "❌ не авторизованный доступ", http.StatusUnauthorized)
+				problem.Write(w, r, problem.Unauthorized("Неверный токен авторизации"))
 				return
 			}
 
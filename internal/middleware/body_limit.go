@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
+	"github.com/NarthurN/GoXML_JSON/pkg/logger"
+)
+
+// MaxBodyBytes - middleware, ограничивающее размер тела запроса.
+// Запрос с заведомо большим Content-Length сразу получает 413; в остальных случаях
+// тело оборачивается http.MaxBytesReader, и чтение сверх лимита возвращает *http.MaxBytesError.
+// Конвейерный режим (?mode=stream) не держит документ в памяти, поэтому для него
+// действует отдельный лимит streamLimit. Лимит 0 снимает ограничение.
+func MaxBodyBytes(logger *logger.Logger, limit, streamLimit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limit
+			if r.URL.Query().Get("mode") == "stream" {
+				limit = streamLimit
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				logger.Logf("❌ тело запроса слишком большое: remote=%s, path=%s, content_length=%d, limit=%d", r.RemoteAddr, r.URL.Path, r.ContentLength, limit)
+				problem.Write(w, r, problem.PayloadTooLarge(fmt.Sprintf("Тело запроса больше %d байт", limit)))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
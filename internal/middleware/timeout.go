@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NarthurN/GoXML_JSON/internal/problem"
+	"github.com/NarthurN/GoXML_JSON/pkg/logger"
+)
+
+// Timeout - middleware, ограничивающее время обработки запроса.
+// Обработчик получает контекст с дедлайном; если он не успел ответить,
+// клиент получает 504 в формате application/problem+json.
+// Конвейерный режим (?mode=stream) пересылает документ, пока клиент его передает, поэтому для него
+// действует отдельный таймаут streamTimeout. Таймаут 0 снимает ограничение.
+func Timeout(logger *logger.Logger, timeout, streamTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := timeout
+			if r.URL.Query().Get("mode") == "stream" {
+				timeout = streamTimeout
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &trackingWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && !tw.written {
+				logger.Logf("❌ превышено время обработки запроса: remote=%s, path=%s, timeout=%s", r.RemoteAddr, r.URL.Path, timeout)
+				problem.Write(w, r, problem.Timeout("Запрос не обработан за "+timeout.String()))
+			}
+		})
+	}
+}
+
+// trackingWriter запоминает, начал ли обработчик отправлять ответ
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,20 @@
+package models
+
+import "fmt"
+
+// DuplicateError - несколько записей пакета имеют один ID пользователя
+type DuplicateError struct {
+	UserID  string `json:"id"`      // Повторяющийся ID
+	Indexes []int  `json:"indexes"` // Номера записей с этим ID в документе, начиная с 0
+	Kept    int    `json:"kept"`    // Номер записи, попавшей в результат; -1 - ни одной
+	Policy  string `json:"policy"`  // Примененная политика обработки дубликатов
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s: id %q в записях %v (политика %s)", ErrDuplicateID, e.UserID, e.Indexes, e.Policy)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrDuplicateID)
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicateID
+}
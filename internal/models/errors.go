@@ -4,16 +4,43 @@ import "errors"
 
 // Ошибки
 var (
-	// Ошибки валидации
-	ErrEmptyID    = errors.New("❌ пустой id")
-	ErrEmptyName  = errors.New("❌ пустое имя")
-	ErrEmptyEmail = errors.New("❌ пустой email")
-	ErrInvalidAge = errors.New("❌ некорректный возраст")
-
 	// Ошибки парсинга
 	ErrEmptyUsers = errors.New("❌ нет пользователей в XML")
+	ErrInvalidXML = errors.New("❌ ошибка при парсинге XML")
+
+	ErrNamespaceNotAccepted = errors.New("❌ пространство имен не разрешено")
+	ErrSOAPFault            = errors.New("❌ SOAP Fault")
+	ErrSchemaValidation     = errors.New("❌ документ не соответствует XSD схеме")
+	ErrUnsupportedCharset   = errors.New("❌ неподдерживаемая кодировка документа")
+
+	ErrXMLLimitExceeded = errors.New("❌ документ превышает ограничения")
+	ErrDoctypeForbidden = errors.New("❌ DOCTYPE и объявления сущностей запрещены")
+	ErrTooManyUsers     = errors.New("❌ слишком много пользователей в документе")
 
 	// Ошибки преобразования
 	ErrEmptyData = errors.New("❌ данные пусты")
 	ErrNoUsers   = errors.New("❌ нет пользователей")
+
+	ErrDuplicateID = errors.New("❌ повторяющийся ID пользователя")
+
+	// Ошибки отправки
+	ErrCircuitOpen   = errors.New("❌ сервер-получатель недоступен, отправка приостановлена")
+	ErrBatchTooLarge = errors.New("❌ пользователь не помещается в пакет")
+
+	ErrInvalidUpstreamResponse = errors.New("❌ некорректный ответ сервера-получателя")
+
+	// Ошибки конфигурации
+	ErrInvalidAgeGroups   = errors.New("❌ некорректная таблица возрастных групп")
+	ErrInvalidMapping     = errors.New("❌ некорректный маппинг полей")
+	ErrInvalidPassthrough = errors.New("❌ неизвестный режим переноса полей")
+	ErrInvalidSchema      = errors.New("❌ некорректная XSD схема")
+
+	ErrInvalidDuplicatePolicy = errors.New("❌ неизвестная политика обработки дубликатов")
+	ErrInvalidXMLOutput       = errors.New("❌ некорректный формат выходного XML")
+
+	// Ошибки запроса
+	ErrUnknownAgeScheme = errors.New("❌ неизвестная схема возрастных групп")
+	ErrUnknownMapping   = errors.New("❌ неизвестный маппинг полей")
+
+	ErrDuplicatePolicyNotStreamable = errors.New("❌ политика обработки дубликатов требует весь пакет и недоступна в потоковом режиме")
 )
@@ -0,0 +1,27 @@
+package models
+
+import "fmt"
+
+// Position - место в исходном XML документе
+type Position struct {
+	Line    int    `json:"line,omitempty"`    // Строка, начиная с 1
+	Column  int    `json:"column,omitempty"`  // Колонка в байтах, начиная с 1
+	Snippet string `json:"snippet,omitempty"` // Фрагмент строки документа
+}
+
+// PositionError - ошибка разбора XML с местом в документе
+type PositionError struct {
+	Position
+	Err error
+}
+
+func (e *PositionError) Error() string {
+	if e.Snippet == "" {
+		return fmt.Sprintf("%v (строка %d, колонка %d)", e.Err, e.Line, e.Column)
+	}
+	return fmt.Sprintf("%v (строка %d, колонка %d: %s)", e.Err, e.Line, e.Column, e.Snippet)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
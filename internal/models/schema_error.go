@@ -0,0 +1,32 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaViolation - нарушение XSD схемы в документе
+type SchemaViolation struct {
+	Line    int    `json:"line"`    // Строка, на которой найдено нарушение
+	Column  int    `json:"column"`  // Колонка, на которой найдено нарушение
+	Path    string `json:"path"`    // Путь к элементу: /users/user[2]/age
+	Message string `json:"message"` // Описание нарушения
+}
+
+// SchemaError - документ не соответствует XSD схеме
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, fmt.Sprintf("%d:%d %s: %s", v.Line, v.Column, v.Path, v.Message))
+	}
+	return fmt.Sprintf("%s: %s", ErrSchemaValidation, strings.Join(messages, "; "))
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrSchemaValidation)
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaValidation
+}
@@ -0,0 +1,48 @@
+package models
+
+// Статусы ответа сервера-получателя
+const (
+	UpstreamStatusSuccess  = "success"  // Приняты все пользователи
+	UpstreamStatusPartial  = "partial"  // Часть пользователей отклонена
+	UpstreamStatusRejected = "rejected" // Отклонены все пользователи
+)
+
+// UpstreamResponse - ответ сервера-получателя на отправку пользователей
+// (ResponseData в cmd/test_server)
+type UpstreamResponse struct {
+	Status      string         `json:"status"`                 // success, partial или rejected
+	Message     string         `json:"message"`                // Сообщение сервера
+	ReceivedAt  string         `json:"received_at,omitempty"`  // Время получения запроса
+	ProcessedBy string         `json:"processed_by,omitempty"` // Имя сервера
+	UserCount   int            `json:"user_count"`             // Число принятых пользователей
+	Users       []JSONUser     `json:"users,omitempty"`        // Принятые пользователи
+	Rejected    []RejectedUser `json:"rejected,omitempty"`     // Отклоненные пользователи с причинами
+	Results     []UserResult   `json:"results,omitempty"`      // Результат по каждому пользователю (ответ 207)
+}
+
+// Статусы пользователя в результатах сервера-получателя
+const (
+	UserStatusAccepted = "accepted"
+	UserStatusRejected = "rejected"
+)
+
+// UserResult - результат приема одного пользователя сервером-получателем
+type UserResult struct {
+	Index  int    `json:"index"`            // Номер пользователя в отправленном массиве, начиная с 0
+	ID     string `json:"id"`               // ID пользователя
+	Status string `json:"status"`           // accepted или rejected
+	Reason string `json:"reason,omitempty"` // Причина отказа
+}
+
+// DeliveredUser - пользователь, принятый сервером-получателем
+type DeliveredUser struct {
+	Index int    `json:"index"` // Номер записи в XML документе, начиная с 0
+	ID    string `json:"id"`    // ID пользователя
+}
+
+// RejectedUser - пользователь, отклоненный сервером-получателем
+type RejectedUser struct {
+	Index  int    `json:"index"`  // Номер пользователя в отправленном массиве (в итоге отправки - номер записи в документе)
+	ID     string `json:"id"`     // ID пользователя
+	Reason string `json:"reason"` // Причина отказа
+}
@@ -1,6 +1,11 @@
 package models
 
-import "encoding/xml"
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"sort"
+)
 
 // XMLUsers - структура для хранения пользователей из XML
 type XMLUsers struct {
@@ -10,16 +15,70 @@ type XMLUsers struct {
 
 // XMLUser - структура для хранения одного пользователя из XML
 type XMLUser struct {
-	ID    string `xml:"id,attr"` // ID пользователя
-	Name  string `xml:"name"`    // Имя пользователя
-	Email string `xml:"email"`   // Email пользователя
-	Age   int    `xml:"age"`     // Возраст пользователя
+	ID        string `xml:"id,attr"`   // ID пользователя
+	Name      string `xml:"name"`      // Имя пользователя
+	Email     string `xml:"email"`     // Email пользователя
+	Age       int    `xml:"-"`         // Возраст пользователя; заполняется из AgeText при чтении документа
+	AgeText   string `xml:"age"`       // Возраст, как он записан в XML; число проверяется при валидации
+	BirthDate string `xml:"birthdate"` // Дата рождения, по которой вычисляется возраст
+
+	Extra    map[string]string `xml:"-"` // Дополнительные поля, переносимые в JSON
+	Position Position          `xml:"-"` // Место записи в документе (нулевое - не из документа)
+
+	// Нераспознанные данные заполняются только при включенном переносе в JSON
+	UnknownAttrs    []xml.Attr `xml:",any,attr"` // Нераспознанные атрибуты <user>
+	UnknownElements []XMLNode  `xml:",any"`      // Нераспознанные дочерние элементы <user>
 }
 
 // JSONUser - структура для хранения одного пользователя в формате JSON
 type JSONUser struct {
-	ID       string `json:"id"`        // ID пользователя
-	FullName string `json:"full_name"` // Имя пользователя
-	Email    string `json:"email"`     // Email пользователя
-	AgeGroup string `json:"age_group"` // Возрастная группа пользователя
+	ID           string `json:"id"`                       // ID пользователя
+	FullName     string `json:"full_name"`                // Имя пользователя
+	Email        string `json:"email"`                    // Email пользователя
+	AgeGroup     string `json:"age_group"`                // Возрастная группа пользователя
+	AgeGroupCode string `json:"age_group_code,omitempty"` // Машинный код возрастной группы
+
+	Extra map[string]any `json:"-"` // Дополнительные поля, добавляемые в объект на верхнем уровне
+	Index int            `json:"-"` // Номер записи в XML документе, начиная с 0
+}
+
+// MarshalJSON добавляет дополнительные поля в JSON объект пользователя.
+// Стандартные поля имеют приоритет, дополнительные идут после них по алфавиту.
+func (u JSONUser) MarshalJSON() ([]byte, error) {
+	type plain JSONUser
+	data, err := json.Marshal(plain(u))
+	if err != nil || len(u.Extra) == 0 {
+		return data, err
+	}
+
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(u.Extra))
+	for key := range u.Extra {
+		if _, ok := known[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for _, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(u.Extra[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Коды ошибок валидации записей
+const (
+	CodeEmptyID           = "EMPTY_ID"
+	CodeEmptyName         = "EMPTY_NAME"
+	CodeEmptyEmail        = "EMPTY_EMAIL"
+	CodeInvalidEmail      = "INVALID_EMAIL"
+	CodeInvalidDomain     = "INVALID_EMAIL_DOMAIN"
+	CodeEmailTooLong      = "EMAIL_TOO_LONG"
+	CodeBlockedDomain     = "BLOCKED_EMAIL_DOMAIN"
+	CodeInvalidAge        = "INVALID_AGE"
+	CodeInvalidBirthDate  = "INVALID_BIRTHDATE"
+	CodeFutureBirthDate   = "FUTURE_BIRTHDATE"
+	CodeUnknownAgeGroup   = "UNKNOWN_AGE_GROUP"
+	CodeValidationFailure = "VALIDATION_FAILED"
+)
+
+// Ошибки валидации записей. Это шаблоны без номера записи:
+// конвертер дополняет их номером, ID пользователя и переведенным сообщением.
+var (
+	ErrEmptyID    = &ValidationError{Field: "id", Code: CodeEmptyID, Message: "пустой id"}
+	ErrEmptyName  = &ValidationError{Field: "name", Code: CodeEmptyName, Message: "пустое имя"}
+	ErrEmptyEmail = &ValidationError{Field: "email", Code: CodeEmptyEmail, Message: "пустой email"}
+	ErrInvalidAge = &ValidationError{Field: "age", Code: CodeInvalidAge, Message: "некорректный возраст"}
+
+	ErrInvalidEmail       = &ValidationError{Field: "email", Code: CodeInvalidEmail, Message: "некорректный email"}
+	ErrInvalidEmailDomain = &ValidationError{Field: "email", Code: CodeInvalidDomain, Message: "некорректный домен email"}
+	ErrEmailTooLong       = &ValidationError{Field: "email", Code: CodeEmailTooLong, Message: "слишком длинный email"}
+	ErrBlockedEmailDomain = &ValidationError{Field: "email", Code: CodeBlockedDomain, Message: "домен email запрещен"}
+
+	ErrInvalidBirthDate = &ValidationError{Field: "birthdate", Code: CodeInvalidBirthDate, Message: "некорректная дата рождения"}
+	ErrFutureBirthDate  = &ValidationError{Field: "birthdate", Code: CodeFutureBirthDate, Message: "дата рождения в будущем"}
+
+	ErrUnknownAgeGroup = &ValidationError{Field: "age_group", Code: CodeUnknownAgeGroup, Message: "неизвестная возрастная группа"}
+)
+
+// ValidationError - запись пользователя не прошла валидацию.
+// Извлекается из объединенной ошибки конвертера через errors.As;
+// errors.Is сравнивает ошибки по коду, например errors.Is(err, ErrEmptyEmail).
+type ValidationError struct {
+	Index   int    `json:"index"`   // Номер записи в документе, начиная с 0
+	UserID  string `json:"id"`      // ID пользователя из записи
+	Field   string `json:"field"`   // Поле записи, не прошедшее проверку
+	Code    string `json:"code"`    // Машинный код ошибки: EMPTY_EMAIL, INVALID_AGE...
+	Message string `json:"message"` // Сообщение на языке запроса
+
+	Position // Место записи в документе: строка, колонка и фрагмент
+
+	Err    error `json:"-"` // Исходная ошибка с подробностями
+	record bool  // Ошибка привязана к записи документа
+}
+
+// NewValidationError привязывает ошибку валидации cause к записи документа.
+// Поле и код берутся из шаблона в цепочке cause, иначе код - VALIDATION_FAILED.
+func NewValidationError(index int, userID string, cause error) *ValidationError {
+	e := &ValidationError{
+		Index:   index,
+		UserID:  userID,
+		Code:    CodeValidationFailure,
+		Message: cause.Error(),
+		Err:     cause,
+		record:  true,
+	}
+
+	var kind *ValidationError
+	if errors.As(cause, &kind) {
+		e.Field, e.Code, e.Message = kind.Field, kind.Code, kind.Message
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	if !e.record {
+		return e.Message
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("user #%d (id %q, строка %d): %s: %v", e.Index, e.UserID, e.Line, e.Code, e.Err)
+	}
+	return fmt.Sprintf("user #%d (id %q): %s: %v", e.Index, e.UserID, e.Code, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает ошибки валидации по коду
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	return ok && t.Code == e.Code
+}
@@ -0,0 +1,44 @@
+package models
+
+import "fmt"
+
+// Коды предупреждений о записях
+const (
+	CodeMixedScriptName = "MIXED_SCRIPT_NAME"
+)
+
+// Предупреждения о записях. Это шаблоны без номера записи, как и ошибки валидации.
+var (
+	WarnMixedScriptName = &Warning{Field: "name", Code: CodeMixedScriptName, Message: "слово в имени смешивает буквы разных алфавитов"}
+)
+
+// Warning - запись прошла валидацию и попала в результат, но ее стоит проверить,
+// например, в имени латинские буквы подменяют похожие кириллические.
+// Объединяется с ошибками конвертера через errors.Join и извлекается через errors.As.
+type Warning struct {
+	Index   int    `json:"index"`           // Номер записи в документе, начиная с 0
+	UserID  string `json:"id"`              // ID пользователя из записи
+	Field   string `json:"field"`           // Поле записи, вызвавшее предупреждение
+	Code    string `json:"code"`            // Машинный код предупреждения
+	Message string `json:"message"`         // Сообщение на языке запроса
+	Value   string `json:"value,omitempty"` // Подозрительный фрагмент значения
+
+	Position // Место записи в документе: строка, колонка и фрагмент
+}
+
+// NewWarning привязывает предупреждение kind к записи документа
+func NewWarning(index int, userID string, kind *Warning, value string) *Warning {
+	w := *kind
+	w.Index, w.UserID, w.Value = index, userID, value
+	return &w
+}
+
+func (w *Warning) Error() string {
+	return fmt.Sprintf("⚠️ user #%d (id %q): %s: %q", w.Index, w.UserID, w.Code, w.Value)
+}
+
+// Is сравнивает предупреждения по коду
+func (w *Warning) Is(target error) bool {
+	t, ok := target.(*Warning)
+	return ok && t.Code == w.Code
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// XMLNode - произвольный XML элемент: используется, когда структура записи
+// заранее не известна (маппинг полей, неизвестные элементы пользователя)
+type XMLNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []XMLNode  `xml:",any"`
+}
+
+// Find ищет значение по пути относительно элемента.
+// Путь состоит из имен дочерних элементов через "/", последний сегмент
+// может ссылаться на атрибут через "@": "contact/email", "@id", "profile/@age".
+// Пустой путь или "." означает текст самого элемента.
+func (n *XMLNode) Find(path string) (string, bool) {
+	current := n
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == "" || segment == ".":
+			continue
+		case strings.HasPrefix(segment, "@"):
+			if i != len(segments)-1 {
+				return "", false
+			}
+			return current.Attr(segment[1:])
+		default:
+			child := current.Child(segment)
+			if child == nil {
+				return "", false
+			}
+			current = child
+		}
+	}
+	return current.Content, true
+}
+
+// Child возвращает первый дочерний элемент с указанным локальным именем
+func (n *XMLNode) Child(name string) *XMLNode {
+	for i := range n.Nodes {
+		if n.Nodes[i].XMLName.Local == name {
+			return &n.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// Attr возвращает значение атрибута с указанным локальным именем
+func (n *XMLNode) Attr(name string) (string, bool) {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
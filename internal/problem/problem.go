@@ -0,0 +1,149 @@
+// Пакет для ответов об ошибках в формате RFC 7807 (application/problem+json)
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ContentType - тип содержимого ответа об ошибке
+const ContentType = "application/problem+json"
+
+// Типы ошибок (относительные URI, разрешаются относительно адреса сервера)
+const (
+	TypeUnauthorized        = "/problems/unauthorized"
+	TypeBadRequest          = "/problems/bad-request"
+	TypeInvalidXML          = "/problems/invalid-xml"
+	TypeUnsupportedMedia    = "/problems/unsupported-media-type"
+	TypePayloadTooLarge     = "/problems/payload-too-large"
+	TypeSchemaViolation     = "/problems/schema-violation"
+	TypeValidationFailed    = "/problems/validation-failed"
+	TypeUpstreamFailed      = "/problems/upstream-failed"
+	TypeUpstreamUnavailable = "/problems/upstream-unavailable"
+	TypeTimeout             = "/problems/timeout"
+	TypeInternal            = "/problems/internal"
+)
+
+// Problem - описание ошибки по RFC 7807
+type Problem struct {
+	Type       string `json:"type"`                 // URI типа ошибки
+	Title      string `json:"title"`                // Краткое описание типа ошибки
+	Status     int    `json:"status"`               // HTTP статус
+	Detail     string `json:"detail,omitempty"`     // Описание конкретного случая
+	Instance   string `json:"instance,omitempty"`   // Путь запроса
+	RequestID  string `json:"requestId,omitempty"`  // ID запроса для поиска в логах
+	Errors     any    `json:"errors,omitempty"`     // Список нарушений: записи или XSD схема
+	Duplicates any    `json:"duplicates,omitempty"` // Повторяющиеся ID пользователей
+	Delivery   any    `json:"delivery,omitempty"`   // Пакеты, отправленные до ошибки
+	Line       int    `json:"line,omitempty"`       // Строка документа с ошибкой разбора
+	Column     int    `json:"column,omitempty"`     // Колонка (в байтах)
+	Snippet    string `json:"snippet,omitempty"`    // Фрагмент строки документа
+}
+
+// New создает описание ошибки
+func New(status int, typ, title, detail string) *Problem {
+	return &Problem{Type: typ, Title: title, Status: status, Detail: detail}
+}
+
+// WithErrors добавляет к ошибке список нарушений
+func (p *Problem) WithErrors(errors any) *Problem {
+	p.Errors = errors
+	return p
+}
+
+// WithDuplicates добавляет к ошибке описания повторяющихся ID
+func (p *Problem) WithDuplicates(duplicates any) *Problem {
+	p.Duplicates = duplicates
+	return p
+}
+
+// WithDelivery добавляет к ошибке пакеты, которые уже были отправлены, когда она случилась
+func (p *Problem) WithDelivery(delivery any) *Problem {
+	p.Delivery = delivery
+	return p
+}
+
+// WithPosition добавляет к ошибке место в документе
+func (p *Problem) WithPosition(line, column int, snippet string) *Problem {
+	p.Line, p.Column, p.Snippet = line, column, snippet
+	return p
+}
+
+// Write отправляет ошибку клиенту, дополняя ее путем и ID запроса
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) error {
+	p.Instance = r.URL.Path
+	p.RequestID = middleware.GetReqID(r.Context())
+	if p.RequestID != "" {
+		w.Header().Set(middleware.RequestIDHeader, p.RequestID)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(p)
+}
+
+// Unauthorized - запрос без действительного токена
+func Unauthorized(detail string) *Problem {
+	return New(http.StatusUnauthorized, TypeUnauthorized, "Не авторизованный доступ", detail)
+}
+
+// BadRequest - некорректные параметры или тело запроса
+func BadRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, TypeBadRequest, "Некорректный запрос", detail)
+}
+
+// InvalidXML - тело запроса не удалось разобрать как XML
+func InvalidXML(detail string) *Problem {
+	return New(http.StatusBadRequest, TypeInvalidXML, "Ошибка при парсинге XML", detail)
+}
+
+// UnsupportedMediaType - документ в неподдерживаемой кодировке
+func UnsupportedMediaType(detail string) *Problem {
+	return New(http.StatusUnsupportedMediaType, TypeUnsupportedMedia, "Неподдерживаемый формат тела запроса", detail)
+}
+
+// PayloadTooLarge - тело запроса или число записей превышает лимит
+func PayloadTooLarge(detail string) *Problem {
+	return New(http.StatusRequestEntityTooLarge, TypePayloadTooLarge, "Слишком большой документ", detail)
+}
+
+// SchemaViolation - документ не соответствует XSD схеме
+func SchemaViolation(violations any) *Problem {
+	return New(http.StatusBadRequest, TypeSchemaViolation, "Документ не соответствует XSD схеме", "").WithErrors(violations)
+}
+
+// ValidationFailed - в документе нет ни одной валидной записи
+func ValidationFailed(errors any) *Problem {
+	return New(http.StatusBadRequest, TypeValidationFailed, "Не найдено валидных пользователей в предоставленных данных", "").WithErrors(errors)
+}
+
+// InvalidRecords - записи пользователей в JSON не прошли проверку, документ не построен
+func InvalidRecords(errors any) *Problem {
+	return New(http.StatusBadRequest, TypeValidationFailed, "Записи пользователей не прошли проверку", "").WithErrors(errors)
+}
+
+// UpstreamFailed - сервер-получатель недоступен или ответил ошибкой
+func UpstreamFailed(detail string) *Problem {
+	return New(http.StatusBadGateway, TypeUpstreamFailed, "Ошибка при отправке JSON пользователей", detail)
+}
+
+// UpstreamUnavailable - сервер-получатель признан неисправным, отправка приостановлена
+func UpstreamUnavailable(detail string) *Problem {
+	return New(http.StatusServiceUnavailable, TypeUpstreamUnavailable, "Сервер-получатель временно недоступен", detail)
+}
+
+// Timeout - запрос не уложился в отведенное время
+func Timeout(detail string) *Problem {
+	return New(http.StatusGatewayTimeout, TypeTimeout, "Превышено время обработки запроса", detail)
+}
+
+// Internal - внутренняя ошибка сервера
+func Internal(detail string) *Problem {
+	return New(http.StatusInternalServerError, TypeInternal, "Внутренняя ошибка сервера", detail)
+}
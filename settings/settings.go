@@ -10,9 +10,41 @@ const (
 	// OutputFile - файл для логов
 	OutputFile = "provider.log"
 
-	// ClientTimeout - таймаут для HTTP запросов
+	// ClientTimeout - таймаут на обработку всего запроса к сервису, включая все попытки отправки
 	ClientTimeout = 10 * time.Second
 
+	// StreamTimeout - таймаут на обработку запроса в конвейерном режиме (?mode=stream).
+	// Документ пересылается, пока клиент его передает, поэтому по умолчанию время не ограничено (0);
+	// запрос прерывается, только если клиент отключился
+	StreamTimeout time.Duration = 0
+
+	// ClientAttemptTimeout - таймаут одной попытки отправки на сервер-получатель.
+	// Должен быть заметно меньше ClientTimeout, иначе после таймаута попытки не остается времени на повтор
+	ClientAttemptTimeout = 3 * time.Second
+
+	// Повтор отправки пользователей при сетевых ошибках и статусах 408, 429, 502, 503, 504.
+	// Паузы растут вдвое от ClientRetryBaseDelay и случайно сокращаются на долю до ClientRetryJitter;
+	// Retry-After сервера учитывается, если не длиннее ClientRetryMaxDelay.
+	ClientMaxAttempts    = 3 // Число попыток, включая первую (1 - без повторов)
+	ClientRetryBaseDelay = 200 * time.Millisecond
+	ClientRetryMaxDelay  = 2 * time.Second
+	ClientRetryJitter    = 0.5
+
+	// Отправка пользователей пакетами: сервер-получатель принимает не больше 500 записей и 1 МБ
+	// в одном запросе (0 - без ограничения). Пакеты отправляются по BatchConcurrency одновременно.
+	BatchMaxUsers    = 500
+	BatchMaxBytes    = 1 << 20
+	BatchConcurrency = 1
+
+	// Предохранитель перед сервером-получателем: если среди последних BreakerWindow попыток
+	// (не меньше BreakerMinRequests) доля ошибок достигла BreakerFailureRate, запросы
+	// отклоняются сразу с 503 на BreakerCoolDown, затем пропускаются пробные попытки
+	BreakerWindow           = 20
+	BreakerMinRequests      = 5
+	BreakerFailureRate      = 0.5
+	BreakerCoolDown         = 30 * time.Second
+	BreakerHalfOpenRequests = 1 // Успешных пробных попыток, чтобы снова пропускать все запросы
+
 	// Настроки сервера
 	ServerHost      = "localhost"
 	ServerPort      = "8080"
@@ -20,4 +52,58 @@ const (
 
 	// ClientURL - базовый URL для HTTP запросов
 	ClientURL = "http://localhost:8081/users"
+
+	// AgeGroupsFile - файл со схемами возрастных групп
+	AgeGroupsFile = "age_groups.json"
+
+	// MappingsDir - каталог с маппингами форматов XML партнеров (*.json)
+	MappingsDir = "mappings"
+
+	// PassthroughMode - перенос нераспознанных элементов <user> в JSON:
+	// "" - отключен, "flatten" - на верхний уровень объекта, "extra" - под ключом extra
+	PassthroughMode = ""
+
+	// AcceptedNamespaces - разрешенные пространства имен коллекции пользователей через запятую.
+	// Пустая строка разрешает любые; элементы без пространства имен принимаются всегда.
+	AcceptedNamespaces = ""
+
+	// LabelLanguage - язык названий возрастных групп, если клиент не прислал Accept-Language
+	LabelLanguage = "ru"
+
+	// AsOfDate - дата (2006-01-02), на которую возраст вычисляется по дате рождения.
+	// Пустая строка означает текущую дату.
+	AsOfDate = ""
+
+	// BlockedEmailDomainsFile - список запрещенных доменов email (одноразовая почта и т.п.),
+	// один домен в строке. Если файла нет, домены не проверяются.
+	BlockedEmailDomainsFile = "blocked_email_domains.txt"
+
+	// DuplicatePolicy - обработка записей с повторяющимся ID, если запрос не задал свою:
+	// "keep-first", "keep-last", "reject" (отклонить все), "merge" (заполнить пустые поля первой записи из остальных)
+	DuplicatePolicy = "keep-first"
+
+	// NameTitleCase - приводить имена к виду «Иван Петров» (первая буква каждого слова заглавная)
+	NameTitleCase = false
+
+	// HomoglyphWarnings - предупреждать в ответе об именах, в словах которых смешаны
+	// латиница и кириллица (например, латинская "a" вместо кириллической "а")
+	HomoglyphWarnings = true
+
+	// Ограничения на входной документ (0 - без ограничения).
+	// DOCTYPE и объявления сущностей отклоняются всегда.
+	MaxBodyBytes  = 100 << 20 // Размер тела запроса, байт (413)
+	MaxUsers      = 100000    // Число записей <user> (413)
+	MaxXMLDepth   = 32        // Вложенность элементов (400)
+	MaxTextLength = 64 << 10  // Длина текста элемента, байт (400)
+	MaxAttrLength = 4 << 10   // Длина значения атрибута, байт (400)
+
+	// Ограничения для конвейерного режима (?mode=stream). Документ в нем не держится в памяти,
+	// поэтому по умолчанию размер не ограничен и пересылаются файлы в несколько ГБ.
+	// Ограничения на вложенность и длину текста и атрибутов действуют так же, как выше.
+	StreamMaxBodyBytes = 0 // Размер тела запроса, байт (413)
+	StreamMaxUsers     = 0 // Число записей <user> (413)
+
+	// SchemaFile - XSD схема, по которой проверяются входящие документы (например, users.xsd).
+	// Пустая строка отключает проверку.
+	SchemaFile = ""
 )